@@ -0,0 +1,232 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+package mux
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/issue9/errwrap"
+
+	"github.com/issue9/mux/v9/header"
+	"github.com/issue9/mux/v9/types"
+)
+
+// 改写规则的最大嵌套次数，超过此值将返回 404。
+const maxRewrites = 10
+
+type (
+	// 路由项的别名、重定向或是改写规则
+	alias struct {
+		target   string // 目标路由项
+		redirect int    // 重定向的状态码，为 0 表示改写为 target 之后重新匹配。
+		strict   bool   // target 是否必须为已注册的路由项，由 [Router.Alias] 添加的别名为 true。
+	}
+
+	// 需要重定向的别名所匹配的节点
+	aliasNode struct {
+		types.Node
+		alias *alias
+	}
+)
+
+// Alias 为已有的路由项 canonical 添加别名 pattern
+//
+// 访问 pattern 时，如果 redirect 为 0，直接调用 canonical 的处理对象，
+// 否则以 redirect 作为状态码重定向到 canonical，redirect 只能是 3xx。
+// pattern 中的参数按名称映射到 canonical，所以 canonical 中的参数必须都存在于 pattern 中，比如：
+//
+//	r.Get("/posts/{id}", h)
+//	r.Alias("/p/{id}", "/posts/{id}", http.StatusMovedPermanently)
+//
+// 别名仅在 pattern 未匹配任何路由项时才会生效，且 [Router.URL] 对别名始终生成 canonical 的地址。
+// 可以通过 [Router.Remove] 删除别名。
+func (r *Router[T]) Alias(pattern, canonical string, redirect int) *Router[T] {
+	if err := r.TryAlias(pattern, canonical, redirect); err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// TryAlias 为已有的路由项 canonical 添加别名 pattern
+//
+// 与 [Router.Alias] 相同，但是在出错时返回错误信息，而不是 panic。
+func (r *Router[T]) TryAlias(pattern, canonical string, redirect int) error {
+	return r.addAlias(pattern, canonical, redirect, true)
+}
+
+// Redirect 将匹配 pattern 的请求重定向到 target
+//
+// pattern 中解析出的参数会按名称填入 target，再由 [Router.URL] 生成最终的地址，请求中的查询参数也会被保留，比如：
+//
+//	r.Redirect("/archives/{year}/{id}", "/posts/{id}", http.StatusMovedPermanently)
+//
+// target 不要求是已经注册的路由项，但是其中的参数必须都存在于 pattern 中；
+// status 只能是 3xx；
+// 与 [Router.Alias] 相同，仅在 pattern 未匹配任何路由项时才会生效，可以通过 [Router.Remove] 删除。
+func (r *Router[T]) Redirect(pattern, target string, status int) *Router[T] {
+	if err := r.TryRedirect(pattern, target, status); err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// TryRedirect 将匹配 pattern 的请求重定向到 target
+//
+// 与 [Router.Redirect] 相同，但是在出错时返回错误信息，而不是 panic。
+func (r *Router[T]) TryRedirect(pattern, target string, status int) error {
+	if status == 0 {
+		return fmt.Errorf("%s => %s: %w", pattern, target, &types.InvalidValueError{Name: "status", Value: status})
+	}
+	return r.addAlias(pattern, target, status, false)
+}
+
+// Rewrite 将匹配 pattern 的请求在内部改写为 target 之后重新匹配
+//
+// 改写只修改用于匹配的路径，不会修改 [http.Request] 对象，
+// pattern 中解析出的参数会按名称填入 target，且在之后的处理中依然可以获取，比如：
+//
+//	r.Rewrite("/u/{name}", "/users/{name}/profile")
+//
+// 改写之后的路径依然可以被其它的改写规则处理，但是最多只能嵌套 10 次，超过则返回 404。
+// 与 [Router.Alias] 相同，仅在 pattern 未匹配任何路由项时才会生效，可以通过 [Router.Remove] 删除。
+func (r *Router[T]) Rewrite(pattern, target string) *Router[T] {
+	if err := r.TryRewrite(pattern, target); err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// TryRewrite 将匹配 pattern 的请求在内部改写为 target 之后重新匹配
+//
+// 与 [Router.Rewrite] 相同，但是在出错时返回错误信息，而不是 panic。
+func (r *Router[T]) TryRewrite(pattern, target string) error {
+	return r.addAlias(pattern, target, 0, false)
+}
+
+func (r *Router[T]) addAlias(pattern, target string, redirect int, strict bool) error {
+	if err := r.tryAlias(r.pattern(pattern), r.pattern(target), redirect, strict); err != nil {
+		return fmt.Errorf("%s => %s: %w", pattern, target, err)
+	}
+	return nil
+}
+
+func (r *Router[T]) tryAlias(pattern, target string, redirect int, strict bool) error {
+	if redirect != 0 && (redirect < 300 || redirect > 399) {
+		return &types.InvalidValueError{Name: "redirect", Value: redirect}
+	}
+
+	if strict && r.tree.Find(target) == nil {
+		return &types.RouteNotFoundError{Pattern: target}
+	}
+	if r.tree.Find(pattern) != nil {
+		return &types.AmbiguousError{Pattern: pattern, Existing: pattern}
+	}
+
+	names, err := r.aliasTree.Params(pattern)
+	if err != nil {
+		return err
+	}
+	tnames, err := r.tree.Params(target)
+	if err != nil {
+		return err
+	}
+	for _, name := range tnames {
+		if !slices.Contains(names, name) {
+			return &types.ParamMismatchError{Pattern: pattern, Param: name, Missing: true}
+		}
+	}
+
+	var h T // 别名节点仅用于匹配，其处理对象不会被调用。
+	if err := r.aliasTree.Add(pattern, h, nil); err != nil {
+		return err
+	}
+	r.aliases.add(pattern, false, &alias{target: target, redirect: redirect, strict: strict})
+	return nil
+}
+
+// Aliases 返回所有的别名
+//
+// 键名为别名，键值为对应的路由项。不包含由 [Router.Redirect] 和 [Router.Rewrite] 添加的规则。
+func (r *Router[T]) Aliases() map[string]string {
+	s := r.aliases.set.Load()
+	if s == nil {
+		return map[string]string{}
+	}
+
+	m := make(map[string]string, len(s.routes))
+	for k, v := range s.routes {
+		if v.strict {
+			m[k] = v.target
+		}
+	}
+	return m
+}
+
+// 删除别名 pattern
+func (r *Router[T]) removeAlias(pattern string) {
+	if _, found := r.aliases.get(pattern); found {
+		r.aliasTree.Remove(pattern)
+		r.aliases.delete(pattern, false)
+	}
+}
+
+// 查找与 ctx.Path 匹配的别名
+//
+// 未找到时返回 nil，且 ctx.Path 保持不变。
+func (r *Router[T]) matchAlias(ctx *types.Context) (types.Node, *alias) {
+	if s := r.aliases.set.Load(); s == nil || len(s.routes) == 0 {
+		return nil, nil
+	}
+
+	path := ctx.Path
+	if node := r.aliasTree.Match(ctx); node != nil {
+		if a, found := r.aliases.get(node.Pattern()); found {
+			return node, a
+		}
+	}
+	ctx.Path = path
+	return nil, nil
+}
+
+// 将 ctx 中的参数填入 a.target 生成新的路径
+func (r *Router[T]) aliasPath(a *alias, ctx *types.Context) (string, error) {
+	params := make(map[string]string, ctx.Count())
+	ctx.Range(func(k, v string) { params[k] = v })
+
+	buf := errwrap.StringBuilder{}
+	var err error
+	if a.strict {
+		err = r.tree.URL(&buf, a.target, params)
+	} else {
+		err = emptyInterceptors.URL(&buf, a.target, params)
+	}
+	if err != nil {
+		return "", err
+	}
+	return buf.String(), buf.Err
+}
+
+// 重定向到 n 对应的目标路由项
+//
+// 与其它仅输出状态码的情况相同，由 [WithStatusHandler] 生成处理对象。
+// 无法生成目标地址时返回 500，但不会将具体的错误信息输出给客户端。
+func (r *Router[T]) redirectAlias(w http.ResponseWriter, req *http.Request, ctx *types.Context, n *aliasNode) {
+	params := make(map[string]string, ctx.Count())
+	ctx.Range(func(k, v string) { params[k] = v })
+
+	u, err := r.URL(false, n.alias.target, params)
+	if err != nil {
+		r.callStatus(w, req, ctx, http.StatusInternalServerError)
+		return
+	}
+	if req.URL.RawQuery != "" {
+		u += "?" + req.URL.RawQuery
+	}
+
+	w.Header().Set(header.Location, u)
+	r.callStatus(w, req, ctx, n.alias.redirect)
+}
@@ -0,0 +1,148 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+package mux
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/issue9/assert/v4"
+	"github.com/issue9/assert/v4/rest"
+
+	"github.com/issue9/mux/v9/header"
+	"github.com/issue9/mux/v9/internal/tree"
+	"github.com/issue9/mux/v9/types"
+)
+
+func TestRouter_Alias(t *testing.T) {
+	a := assert.New(t, false)
+
+	callParams := func(w http.ResponseWriter, r *http.Request, ps types.Route, h http.Handler) {
+		if n := ps.Node(); n != nil {
+			w.Header().Set("X-Pattern", n.Pattern())
+		}
+		w.Header().Set("X-ID", ps.Params().MustString("id", ""))
+		h.ServeHTTP(w, r)
+	}
+	r := NewRouter("def", callParams, http.NotFoundHandler(), methodNotAllowedBuilder, optionsHandlerBuilder, WithURLDomain("https://example.com"))
+	r.Get("/posts/{id:\\d+}", rest.BuildHandler(a, 201, "", nil)).
+		Post("/posts", rest.BuildHandler(a, 202, "", nil))
+
+	r.Alias("/p/{id}", "/posts/{id:\\d+}", 0).
+		Alias("/article-{id}.html", "/posts/{id:\\d+}", http.StatusMovedPermanently)
+
+	// 直接调用
+	rest.Get(a, "/p/5").Do(r).Status(201).
+		Header("X-Pattern", "/posts/{id:\\d+}").
+		Header("X-ID", "5")
+	rest.Delete(a, "/p/5").Do(r).Status(http.StatusMethodNotAllowed)
+	rest.Get(a, "/p/abc").Do(r).Status(http.StatusNotFound) // 不符合 canonical 的参数要求
+
+	// 重定向
+	rest.Get(a, "/article-5.html?page=2").Do(r).
+		Status(http.StatusMovedPermanently).
+		Header(header.Location, "https://example.com/posts/5?page=2")
+
+	// 已有的路由项不受影响
+	rest.Post(a, "/posts", nil).Do(r).Status(202)
+
+	// URL
+	u, err := r.URL(true, "/p/{id}", map[string]string{"id": "5"})
+	a.NotError(err).Equal(u, "https://example.com/posts/5")
+
+	a.Equal(r.Aliases(), map[string]string{
+		"/p/{id}":            "/posts/{id:\\d+}",
+		"/article-{id}.html": "/posts/{id:\\d+}",
+	})
+	a.NotContains(r.Routes(), "/p/{id}")
+
+	// 出错
+	err = r.TryAlias("/p2/{id}", "/not-exists", 0)
+	var rerr *types.RouteNotFoundError
+	a.True(errors.As(err, &rerr))
+	err = r.TryAlias("/p2", "/posts/{id:\\d+}", 0)
+	var perr *types.ParamMismatchError
+	a.True(errors.As(err, &perr)).Equal(perr.Param, "id")
+	a.Error(r.TryAlias("/p2/{id}", "/posts/{id:\\d+}", http.StatusOK))
+	a.Error(r.TryAlias("/posts", "/posts/{id:\\d+}", 0))
+	a.PanicString(func() {
+		r.Alias("/p/{id}", "/posts/{id:\\d+}", 0)
+	}, "/p/{id}")
+
+	// 删除
+	r.Remove("/p/{id}")
+	rest.Get(a, "/p/5").Do(r).Status(http.StatusNotFound)
+	a.Equal(r.Aliases(), map[string]string{"/article-{id}.html": "/posts/{id:\\d+}"})
+
+	r.Clean()
+	a.Empty(r.Aliases())
+	rest.Get(a, "/article-5.html").Do(r).Status(http.StatusNotFound)
+}
+
+func TestRouter_Redirect(t *testing.T) {
+	a := assert.New(t, false)
+
+	r := newRouter(a, "def")
+	r.Get("/posts/{id}", rest.BuildHandler(a, 201, "", nil))
+
+	r.Redirect("/archives/{year}/{id}", "/posts/{id}", http.StatusMovedPermanently).
+		Redirect("/old/{id}", "/new/{id}", http.StatusFound) // 目标可以是不存在的路由项
+
+	rest.Get(a, "/archives/2020/5?page=1").Do(r).
+		Status(http.StatusMovedPermanently).
+		Header(header.Location, "/posts/5?page=1")
+	rest.Post(a, "/old/5", nil).Do(r).
+		Status(http.StatusFound).
+		Header(header.Location, "/new/5")
+	a.Empty(r.Aliases())
+
+	// URL 不受影响
+	u, err := r.URL(false, "/old/{id}", map[string]string{"id": "5"})
+	a.NotError(err).Equal(u, "/old/5")
+
+	a.Error(r.TryRedirect("/p/{id}", "/posts/{id}", 0))
+	a.Error(r.TryRedirect("/p/{id}", "/posts/{id}", http.StatusOK))
+	var perr *types.ParamMismatchError
+	a.True(errors.As(r.TryRedirect("/p", "/posts/{id}", http.StatusFound), &perr))
+
+	// 经过中间件
+	r.Use(tree.BuildTestMiddleware(a, "m1"))
+	rest.Get(a, "/old/5").Do(r).
+		Status(http.StatusFound).
+		Header(header.Location, "/new/5").
+		StringBody(http.StatusText(http.StatusFound) + "\nm1")
+
+	r.Remove("/old/{id}")
+	rest.Get(a, "/old/5").Do(r).Status(http.StatusNotFound)
+}
+
+func TestRouter_Rewrite(t *testing.T) {
+	a := assert.New(t, false)
+
+	callParams := func(w http.ResponseWriter, r *http.Request, ps types.Route, h http.Handler) {
+		w.Header().Set("X-Name", ps.Params().MustString("name", ""))
+		w.Header().Set("X-Path", r.URL.Path)
+		h.ServeHTTP(w, r)
+	}
+	r := NewRouter("def", callParams, http.NotFoundHandler(), methodNotAllowedBuilder, optionsHandlerBuilder)
+	r.Get("/users/{name}/profile", rest.BuildHandler(a, 201, "", nil))
+
+	r.Rewrite("/u/{name}", "/users/{name}/profile").
+		Rewrite("/~{name}", "/u/{name}"). // 多次改写
+		Rewrite("/loop1", "/loop2").
+		Rewrite("/loop2", "/loop1")
+
+	rest.Get(a, "/u/abc").Do(r).Status(201).
+		Header("X-Name", "abc").
+		Header("X-Path", "/u/abc") // 不修改 http.Request
+	rest.Get(a, "/~abc").Do(r).Status(201).Header("X-Name", "abc")
+	rest.Post(a, "/u/abc", nil).Do(r).Status(http.StatusMethodNotAllowed)
+	rest.Get(a, "/loop1").Do(r).Status(http.StatusNotFound)
+
+	r.Remove("/u/{name}")
+	rest.Get(a, "/u/abc").Do(r).Status(http.StatusNotFound)
+	rest.Get(a, "/~abc").Do(r).Status(http.StatusNotFound)
+}
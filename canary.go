@@ -0,0 +1,112 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+package mux
+
+import (
+	"hash/fnv"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/issue9/mux/v9/types"
+)
+
+type (
+	// Canary 按比例分流的 [Matcher] 实现
+	//
+	// 可用于灰度发布，将同一 [Group] 中两个拥有相同路由项的 [Router] 分别作为新旧版本，
+	// 新版本的 [Router] 采用 Canary 作为其 [Matcher]，且需要放在旧版本之前。
+	//
+	//	g.New("canary", mux.NewCanary(10, mux.CanaryCookie("uid")))
+	//	g.New("stable", nil)
+	Canary struct {
+		weight atomic.Int32
+		key    CanaryKey
+	}
+
+	// CanaryKey 从请求中获取用于分流的值
+	//
+	// 相同的值总是会被分配到相同的路由，返回空值表示随机分配。
+	CanaryKey func(*http.Request) string
+)
+
+// NewCanary 声明 [Canary] 实例
+//
+// weight 表示进入当前路由的流量比例，取值范围为 [0, 100]；
+// key 用于保证同一用户始终访问相同的路由，如果为空，表示每次请求都随机分配；
+func NewCanary(weight int, key CanaryKey) *Canary {
+	if key == nil {
+		key = func(*http.Request) string { return "" }
+	}
+
+	c := &Canary{key: key}
+	c.SetWeight(weight)
+	return c
+}
+
+// CanaryCookie 以 Cookie 的值作为分流依据
+func CanaryCookie(name string) CanaryKey {
+	return func(r *http.Request) string {
+		if c, err := r.Cookie(name); err == nil {
+			return c.Value
+		}
+		return ""
+	}
+}
+
+// CanaryHeader 以报头的值作为分流依据
+func CanaryHeader(name string) CanaryKey {
+	return func(r *http.Request) string { return r.Header.Get(name) }
+}
+
+// CanaryClientIP 以客户端的地址作为分流依据
+//
+// trusted 为可信任的代理地址，可参考 [NewCIDR] 中的说明。
+func CanaryClientIP(trusted ...string) CanaryKey {
+	ps := parsePrefixes(trusted)
+	return func(r *http.Request) string {
+		if addr := clientAddr(r, ps); addr.IsValid() {
+			return addr.String()
+		}
+		return ""
+	}
+}
+
+// SetWeight 修改进入当前路由的流量比例
+//
+// 可在运行时调用，取值范围为 [0, 100]，超出范围会触发 panic。
+func (c *Canary) SetWeight(weight int) {
+	if weight < 0 || weight > 100 {
+		panic(&types.InvalidValueError{Name: "weight", Value: weight})
+	}
+	c.weight.Store(int32(weight))
+}
+
+// Weight 进入当前路由的流量比例
+func (c *Canary) Weight() int { return int(c.weight.Load()) }
+
+// String 返回当前比例的描述信息，比如 canary=10%
+func (c *Canary) String() string { return "canary=" + strconv.Itoa(c.Weight()) + "%" }
+
+func (c *Canary) Match(r *http.Request, _ *types.Context) bool {
+	w := c.weight.Load()
+	switch w {
+	case 0:
+		return false
+	case 100:
+		return true
+	}
+
+	var n uint32
+	if k := c.key(r); k != "" {
+		h := fnv.New32a()
+		h.Write([]byte(k))
+		n = h.Sum32() % 100
+	} else {
+		n = rand.Uint32N(100)
+	}
+	return int32(n) < w
+}
@@ -0,0 +1,80 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+package mux
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/issue9/assert/v4"
+	"github.com/issue9/assert/v4/rest"
+
+	"github.com/issue9/mux/v9/header"
+	"github.com/issue9/mux/v9/types"
+)
+
+var _ Matcher = &Canary{}
+
+func TestCanary(t *testing.T) {
+	a := assert.New(t, false)
+
+	a.PanicValue(func() { NewCanary(101, nil) }, &types.InvalidValueError{Name: "weight", Value: 101})
+	a.PanicValue(func() { NewCanary(-1, nil) }, &types.InvalidValueError{Name: "weight", Value: -1})
+
+	c := NewCanary(0, CanaryHeader("uid"))
+	a.Equal(c.Weight(), 0)
+	a.False(c.Match(rest.Get(a, "/path").Header("uid", "1").Request(), types.NewContext()))
+
+	c.SetWeight(100)
+	a.True(c.Match(rest.Get(a, "/path").Header("uid", "1").Request(), types.NewContext()))
+
+	// 粘性
+	c.SetWeight(50)
+	count := 0
+	for i := range 100 {
+		r := rest.Get(a, "/path").Header("uid", strconv.Itoa(i)).Request()
+		matched := c.Match(r, types.NewContext())
+		for range 5 {
+			a.Equal(c.Match(r, types.NewContext()), matched)
+		}
+		if matched {
+			count++
+		}
+	}
+	a.True(count > 20 && count < 80, count)
+
+	c = NewCanary(50, CanaryCookie("uid"))
+	r := rest.Get(a, "/path").Header(header.Cookie, "uid=abc").Request()
+	matched := c.Match(r, types.NewContext())
+	for range 5 {
+		a.Equal(c.Match(r, types.NewContext()), matched)
+	}
+
+	c = NewCanary(50, CanaryClientIP())
+	r = rest.Get(a, "/path").Request()
+	r.RemoteAddr = "8.8.8.8:1234"
+	matched = c.Match(r, types.NewContext())
+	for range 5 {
+		a.Equal(c.Match(r, types.NewContext()), matched)
+	}
+}
+
+func TestGroup_canary(t *testing.T) {
+	a := assert.New(t, false)
+	g := newGroup(a)
+
+	c := NewCanary(0, CanaryHeader("uid"))
+	g.New("canary", c).Get("/path", rest.BuildHandler(a, 201, "", nil))
+	g.New("stable", nil).Get("/path", rest.BuildHandler(a, 202, "", nil))
+
+	rest.Get(a, "/path").Header("uid", "1").Do(g).Status(202)
+
+	c.SetWeight(100)
+	rest.Get(a, "/path").Header("uid", "1").Do(g).Status(201)
+
+	c.SetWeight(0)
+	rest.NewRequest(a, http.MethodGet, "/path").Do(g).Status(202)
+}
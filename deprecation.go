@@ -0,0 +1,99 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+package mux
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/issue9/mux/v9/header"
+)
+
+// Deprecation 路由项的弃用信息
+//
+// 符合要求的路由项在输出时会带上 Deprecation、Sunset 和 Link 报头，
+// 具体可参考 [RFC9745] 和 [RFC8594]。
+//
+// [RFC9745]: https://www.rfc-editor.org/rfc/rfc9745
+// [RFC8594]: https://www.rfc-editor.org/rfc/rfc8594
+type Deprecation struct {
+	// Date 弃用的时间
+	//
+	// 可以是将来的时间，表示将在该时间弃用。
+	// 如果为零值，则不输出 Deprecation 报头，但 Sunset 和 Link 报头依然有效。
+	Date time.Time
+
+	// Sunset 停止服务的时间
+	//
+	// 为零值表示未指定，不会输出 Sunset 报头。
+	Sunset time.Time
+
+	// Successor 替代版本的地址
+	//
+	// 不为空时会输出 Link: <Successor>; rel="successor-version"。
+	Successor string
+
+	// Gone 在 Sunset 之后是否返回 410
+	//
+	// Sunset 为零值时此值无效。
+	Gone bool
+}
+
+// WithDeprecation 将 [Router] 中的所有路由项标记为弃用
+//
+// 可用于 [Group] 中对整个版本的弃用，比如：
+//
+//	g.New("v1", mux.NewPathVersion("", "v1"), mux.WithDeprecation(&mux.Deprecation{...}))
+//
+// 单个路由项或是前缀可以通过 [Router.Deprecate]、[Prefix.Deprecate] 和 [Resource.Deprecate] 单独指定。
+func WithDeprecation(d *Deprecation) Option { return func(o *options) { o.deprecation = d } }
+
+// Deprecate 将路由项 pattern 标记为弃用
+//
+// pattern 为添加路由项时的匹配模式；d 为 nil 表示取消弃用。
+// 针对单个路由项的设置优先于 [Prefix.Deprecate] 和 [WithDeprecation]。
+func (r *Router[T]) Deprecate(pattern string, d *Deprecation) {
+	pattern = r.pattern(pattern)
+	if d == nil {
+		r.deprecations.delete(pattern, false)
+	} else {
+		r.deprecations.add(pattern, false, d)
+	}
+}
+
+// Deprecate 将所有以 [Prefix.Pattern] 开头的路由项标记为弃用
+//
+// d 为 nil 表示取消弃用。存在多个符合要求的前缀时，以最长的前缀为准。
+func (p *Prefix[T]) Deprecate(d *Deprecation) {
+	pattern := p.router.pattern(p.Pattern())
+	if d == nil {
+		p.router.deprecations.delete(pattern, true)
+	} else {
+		p.router.deprecations.add(pattern, true, d)
+	}
+}
+
+// Deprecate 将当前资源标记为弃用
+//
+// 可参考 [Router.Deprecate]。
+func (r *Resource[T]) Deprecate(d *Deprecation) { r.router.Deprecate(r.Pattern(), d) }
+
+// 输出报头，返回值表示是否已经过了 Sunset 且需要返回 410。
+func (d *Deprecation) handle(h http.Header, now time.Time) (gone bool) {
+	if !d.Date.IsZero() {
+		h.Set(header.Deprecation, "@"+strconv.FormatInt(d.Date.Unix(), 10))
+	}
+
+	if !d.Sunset.IsZero() {
+		h.Set(header.Sunset, d.Sunset.UTC().Format(http.TimeFormat))
+	}
+
+	if d.Successor != "" {
+		h.Add(header.Link, "<"+d.Successor+`>; rel="successor-version"`)
+	}
+
+	return d.Gone && !d.Sunset.IsZero() && !now.Before(d.Sunset)
+}
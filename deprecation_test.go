@@ -0,0 +1,96 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+package mux
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/issue9/assert/v4"
+	"github.com/issue9/assert/v4/rest"
+
+	"github.com/issue9/mux/v9/header"
+	"github.com/issue9/mux/v9/internal/tree"
+)
+
+func TestDeprecation_handle(t *testing.T) {
+	a := assert.New(t, false)
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	h := http.Header{}
+	d := &Deprecation{}
+	a.False(d.handle(h, now)).
+		Empty(h.Get(header.Deprecation)).
+		Empty(h.Get(header.Sunset)).
+		Empty(h.Get(header.Link))
+
+	h = http.Header{}
+	d = &Deprecation{
+		Date:      time.Unix(1688169599, 0),
+		Sunset:    time.Date(2024, 6, 30, 23, 59, 59, 0, time.UTC),
+		Successor: "/v2",
+		Gone:      true,
+	}
+	a.False(d.handle(h, now)).
+		Equal(h.Get(header.Deprecation), "@1688169599").
+		Equal(h.Get(header.Sunset), "Sun, 30 Jun 2024 23:59:59 GMT").
+		Equal(h.Get(header.Link), `</v2>; rel="successor-version"`)
+
+	a.True(d.handle(http.Header{}, d.Sunset))
+
+	d.Gone = false
+	a.False(d.handle(http.Header{}, d.Sunset.Add(time.Hour)))
+}
+
+func TestRouter_Deprecate(t *testing.T) {
+	a := assert.New(t, false)
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+
+	date := time.Unix(1688169599, 0)
+	r := newRouter(a, "v1", WithDeprecation(&Deprecation{Date: date, Successor: "/v2"}), WithAllowedCORS(0))
+	r.Use(tree.BuildTestMiddleware(a, "m1"))
+	r.Get("/users", rest.BuildHandler(a, 201, "", nil)).
+		Get("/posts/{id}", rest.BuildHandler(a, 201, "", nil)).
+		Get("/posts/{id}/comments", rest.BuildHandler(a, 201, "", nil)).
+		Get("/tags", rest.BuildHandler(a, 201, "", nil))
+
+	// WithDeprecation
+	rest.Get(a, "/users").Do(r).Status(201).
+		Header(header.Deprecation, "@1688169599").
+		Header(header.Link, `</v2>; rel="successor-version"`)
+
+	// Prefix
+	p := r.Prefix("/posts")
+	p.Deprecate(&Deprecation{Sunset: past, Gone: true})
+	rest.Get(a, "/posts/1").Header(header.Origin, "https://example.com").Do(r).
+		Status(http.StatusGone).Header(header.Deprecation, "").
+		Header(header.AccessControlAllowOrigin, "*").
+		StringBody(http.StatusText(http.StatusGone) + "\nm1")
+	rest.Get(a, "/posts/1/comments").Do(r).Status(http.StatusGone)
+
+	// 路由项优先于前缀
+	r.Prefix("/posts").Resource("/{id}/comments").Deprecate(&Deprecation{Sunset: future, Gone: true})
+	rest.Get(a, "/posts/1/comments").Do(r).Status(201).
+		Header(header.Sunset, future.UTC().Format(http.TimeFormat))
+
+	// 取消
+	p.Deprecate(nil)
+	rest.Get(a, "/posts/1").Do(r).Status(201).Header(header.Link, `</v2>; rel="successor-version"`)
+	r.Deprecate("/posts/{id}/comments", nil)
+	rest.Get(a, "/posts/1/comments").Do(r).Status(201).Header(header.Deprecation, "@1688169599")
+
+	// 未找到的路由项不输出报头
+	rest.Get(a, "/not-exists").Do(r).Status(404).Header(header.Deprecation, "")
+
+	// 未指定 WithDeprecation
+	r = newRouter(a, "v2")
+	r.Get("/users", rest.BuildHandler(a, 201, "", nil)).
+		Get("/tags", rest.BuildHandler(a, 201, "", nil))
+	r.Deprecate("/tags", &Deprecation{Date: past})
+	rest.Get(a, "/users").Do(r).Status(201).Header(header.Deprecation, "")
+	rest.Get(a, "/tags").Do(r).Status(201).Header(header.Sunset, "")
+}
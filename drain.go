@@ -0,0 +1,79 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+package mux
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/issue9/mux/v9/types"
+)
+
+// 记录正在处理的请求数量
+type inflight struct {
+	count    atomic.Int64
+	draining atomic.Bool // 由 Group.Drain 设置，重新添加到 Group 时清除。
+	locker   sync.Mutex
+	done     chan struct{} // 在 draining 状态下，所有请求处理完成之后关闭。
+}
+
+// 开始处理请求
+//
+// 先计数再检测 draining 状态，与 wait 中的顺序相反，
+// 保证 wait 要么能看到该请求，要么该请求能看到 draining 状态。
+// 返回 false 表示已经处于 draining 状态，不应该再处理该请求，此时也无需调用 end。
+func (f *inflight) begin() bool {
+	f.count.Add(1)
+	if f.draining.Load() {
+		f.end()
+		return false
+	}
+	return true
+}
+
+func (f *inflight) end() {
+	if f.count.Add(-1) == 0 && f.draining.Load() {
+		f.locker.Lock()
+		if f.done != nil {
+			close(f.done)
+			f.done = nil
+		}
+		f.locker.Unlock()
+	}
+}
+
+// 等待所有正在处理的请求完成
+func (f *inflight) wait(ctx context.Context) error {
+	f.locker.Lock()
+	done := make(chan struct{})
+	f.done = done
+	f.draining.Store(true)
+	f.locker.Unlock()
+
+	if f.count.Load() > 0 {
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// Drain 移除名为 name 的路由并等待其正在处理的请求完成
+//
+// 与 [Group.Remove] 相同，调用之后新的请求将不再分发给该路由，
+// 但是会等待已经分发给该路由的请求处理完成之后才返回，可用于安全地下线某一路由。
+// 如果 ctx 在请求完成之前结束，则返回 ctx.Err()，此时路由依然已经被移除。
+func (g *Group[T]) Drain(ctx context.Context, name string) error {
+	r := g.remove(name)
+	if r == nil {
+		return &types.RouterNotFoundError{Name: name}
+	}
+	g.notify(types.RouterRemoved, name)
+
+	return r.inflight.wait(ctx)
+}
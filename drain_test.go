@@ -0,0 +1,99 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+package mux
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/issue9/assert/v4"
+	"github.com/issue9/assert/v4/rest"
+)
+
+func TestGroup_Drain(t *testing.T) {
+	a := assert.New(t, false)
+
+	g := newGroup(a)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	r1 := g.New("r1", nil)
+	r1.Get("/slow", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.WriteHeader(201)
+	}))
+	r1.Get("/fast", rest.BuildHandler(a, 202, "", nil))
+	g.New("r2", nil).Get("/fast", rest.BuildHandler(a, 203, "", nil))
+
+	a.Error(g.Drain(context.Background(), "not-exists"))
+
+	w := httptest.NewRecorder()
+	finished := make(chan struct{})
+	go func() {
+		g.ServeHTTP(w, rest.Get(a, "/slow").Request())
+		close(finished)
+	}()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	a.ErrorIs(g.Drain(ctx, "r1"), context.DeadlineExceeded)
+	a.Nil(g.Router("r1"))
+	rest.Get(a, "/fast").Do(g).Status(203) // 新的请求不再分发给 r1
+
+	done := make(chan error)
+	go func() { done <- r1.inflight.wait(context.Background()) }()
+	close(release)
+	a.NotError(<-done)
+	<-finished
+	a.Equal(w.Code, 201)
+
+	// 没有正在处理的请求
+	a.NotError(g.Drain(context.Background(), "r2"))
+	a.Empty(g.Routers())
+
+	// 已经移除的路由不再计数，重新添加之后恢复。
+	r2 := newRouter(a, "r2")
+	r2.Get("/fast", rest.BuildHandler(a, 203, "", nil))
+	a.NotError(g.TryAdd(nil, r2))
+	a.NotError(g.Drain(context.Background(), "r2"))
+	a.False(r2.inflight.begin())
+	g.Add(nil, r2)
+	a.True(r2.inflight.begin())
+	r2.inflight.end()
+	rest.Get(a, "/fast").Do(g).Status(203)
+}
+
+// 复制请求时需要读取请求体，此时请求已经分发给路由，Drain 需要等待其完成。
+func TestGroup_Drain_mirror(t *testing.T) {
+	a := assert.New(t, false)
+
+	g := newGroup(a)
+	g.New("r1", nil).Post("/users", rest.BuildHandler(a, 201, "", nil))
+	g.New("shadow", NewPathVersion("", "v2")).Post("/users", rest.BuildHandler(a, 202, "", nil))
+	a.NotError(g.Mirror("r1", &Mirror{Shadow: "shadow", Percent: 100, MaxBodySize: 1024}))
+
+	pr, pw := io.Pipe()
+	w := httptest.NewRecorder()
+	finished := make(chan struct{})
+	go func() {
+		g.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/users", pr))
+		close(finished)
+	}()
+	_, err := pw.Write([]byte("body")) // 返回时表示请求体已经开始被读取
+	a.NotError(err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	a.ErrorIs(g.Drain(ctx, "r1"), context.DeadlineExceeded)
+
+	a.NotError(pw.Close())
+	<-finished
+	a.Equal(w.Code, 201)
+}
@@ -5,9 +5,11 @@
 package mux
 
 import (
-	"fmt"
 	"net/http"
 	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
 
 	"github.com/issue9/mux/v9/internal/tree"
 	"github.com/issue9/mux/v9/types"
@@ -15,17 +17,38 @@ import (
 
 type (
 	// Group 管理一组 [Router]
+	//
+	// 对 [Group] 中路由列表的修改，比如 [Group.Add] 和 [Group.Remove] 等，
+	// 可以与 [Group.ServeHTTP] 同时进行，读取时无需加锁。
+	// 但是 [Group.Use] 会修改各个 [Router] 的路由树，只有在指定了 [WithLock] 时才能与 [Group.ServeHTTP] 同时进行。
 	Group[T any] struct {
-		routers []*Router[T]
-		ms      []types.Middleware[T]
+		state  atomic.Pointer[groupState[T]]
+		locker sync.Mutex // 修改 state 和 ms 时需要加锁
+		ms     []types.Middleware[T]
 
 		call           CallFunc[T]
-		notFound       T // 所有路由都找不着时调用的方法，该方法应用的中间件中 router 参数是为空的。
 		originNotFound T // 这是未应用中间件的 notFound
 		methodNotAllowedBuilder,
 		optionsBuilder types.BuildNodeHandler[T]
-		options     []Option
-		recoverFunc RecoverFunc
+		options       []Option
+		recoverFunc   RecoverFunc
+		fallThrough   bool
+		notAcceptable bool
+		maintenance   atomic.Pointer[maintenance] // 由 Group.Maintenance 指定，路由未单独设置时采用此值。
+		observers     []types.Observer
+	}
+
+	// Group 中可在运行时修改的内容，每次修改都会生成新的对象。
+	groupState[T any] struct {
+		routers  []*Router[T] // 按 Router.priority 从大到小排列
+		notFound T            // 所有路由都找不着时调用的方法，该方法应用的中间件中 router 参数是为空的。
+	}
+
+	// 合并多个 [Router] 中相同路径的节点，用于生成 405 的处理对象。
+	allowNode struct {
+		pattern string
+		methods []string
+		allow   string
 	}
 )
 
@@ -43,18 +66,21 @@ func NewGroup[T any](
 		panic(err)
 	}
 
-	return &Group[T]{
-		routers: make([]*Router[T], 0, 1),
-		ms:      make([]types.Middleware[T], 0, 10),
+	g := &Group[T]{
+		ms: make([]types.Middleware[T], 0, 10),
 
 		call:                    call,
-		notFound:                notFound,
 		originNotFound:          notFound,
 		methodNotAllowedBuilder: methodNotAllowedBuilder,
 		optionsBuilder:          optionsBuilder,
 		options:                 o,
 		recoverFunc:             opt.recoverFunc,
+		fallThrough:             opt.fallThrough,
+		notAcceptable:           opt.notAcceptable,
+		observers:               opt.observers,
 	}
+	g.state.Store(&groupState[T]{routers: make([]*Router[T], 0, 1), notFound: notFound})
+	return g
 }
 
 func (g *Group[T]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -64,11 +90,30 @@ func (g *Group[T]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// 如果已经在 [NewGroup] 中指定了 Recovery 的相关参数，那么在初始化 g.routers
 	// 时会自动为各个路由添加，无需在此处再次添加 Recovery 的处理。
 
-	for _, router := range g.routers {
-		if ok := router.matcher.Match(r, ctx); ok {
-			router.serveContext(w, r, ctx)
+	state := g.state.Load()
+
+	var notAllowed *allowNode
+	var rejected *HeaderVersion // 第一个拒绝该请求的 HeaderVersion，仅在 notAcceptable 为 true 时有值。
+	for _, router := range state.routers {
+		ctx.Path = r.URL.Path // Matcher 可能会修改 ctx.Path，比如 NewPathVersion 会去掉版本号部分。
+		if ok := router.matcher.Match(r, ctx); !ok {
+			if g.notAcceptable && rejected == nil {
+				rejected, _ = router.matcher.(*HeaderVersion)
+			}
+			ctx.Reset()
+			continue
+		}
+
+		node, served := g.serveRouter(w, r, ctx, router)
+		if served {
 			return
 		}
+		if node != nil { // 405
+			if notAllowed == nil {
+				notAllowed = &allowNode{pattern: node.Pattern()}
+			}
+			notAllowed.methods = append(notAllowed.methods, node.Methods()...)
+		}
 		ctx.Reset()
 	}
 
@@ -79,41 +124,212 @@ func (g *Group[T]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			}
 		}()
 	}
-	g.call(w, r, ctx, g.notFound)
+
+	if notAllowed != nil {
+		notAllowed.build()
+		ctx.SetNode(notAllowed)
+		h := tree.ApplyMiddleware(g.methodNotAllowedBuilder(notAllowed), "", notAllowed.pattern, "", g.ms...)
+		g.call(w, r, ctx, h)
+		return
+	}
+	if rejected != nil {
+		rejected.NotAcceptable(w)
+		return
+	}
+	g.call(w, r, ctx, state.notFound)
+}
+
+// 由 router 处理请求
+//
+// 返回值表示请求是否已经被处理。在 fallThrough 模式下，router 中未找到匹配的路由项时不处理，
+// 如果只是请求方法不匹配，则返回对应的节点。
+func (g *Group[T]) serveRouter(w http.ResponseWriter, r *http.Request, ctx *types.Context, router *Router[T]) (types.Node, bool) {
+	// 在复制请求和查找处理对象之前计数，保证 Group.Drain 不会在已经分发给该路由的请求开始之前返回。
+	if !router.inflight.begin() { // 在加载 state 之后才被 Group.Drain 移除
+		return nil, false
+	}
+	defer router.inflight.end()
+
+	node, h, ok := router.handler(r, ctx)
+	if ok || !g.fallThrough { // 两种模式下都只复制匹配到路由项的请求，由 Router.serve 判断。
+		router.serve(w, r, ctx, node, h, ok, true)
+		return nil, true
+	}
+	return node, false
 }
 
 // New 声明新路由
 //
 // 新路由会继承 [NewGroup] 中指定的参数，其中的 o 可以覆盖由 [NewGroup] 中指定的相关参数；
 func (g *Group[T]) New(name string, matcher Matcher, o ...Option) *Router[T] {
-	o = slices.Concat(g.options, o)
-	r := NewRouter(name, g.call, g.originNotFound, g.methodNotAllowedBuilder, g.optionsBuilder, o...)
-	g.Add(matcher, r)
+	r, err := g.TryNew(name, matcher, o...)
+	if err != nil {
+		panic(err)
+	}
 	return r
 }
 
+// TryNew 声明新路由
+//
+// 与 [Group.New] 相同，但是在出错时返回错误信息，而不是 panic。
+func (g *Group[T]) TryNew(name string, matcher Matcher, o ...Option) (*Router[T], error) {
+	o = slices.Concat(g.options, o)
+	r, err := TryNewRouter(name, g.call, g.originNotFound, g.methodNotAllowedBuilder, g.optionsBuilder, o...)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := g.TryAdd(matcher, r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
 // Add 添加路由
 //
 // matcher 用于判断进入 r 的条件，如果为空，则表示不作判断。
 // 如果有多个 matcher 都符合条件，第一个符合条件的 r 获得优胜；
+//
+// r 会按 [WithPriority] 指定的优先级插入到合适的位置，相同优先级的按添加顺序排列。
+// 如果已经存在同名的路由，将触发 panic。
 func (g *Group[T]) Add(matcher Matcher, r *Router[T]) {
+	if err := g.TryAdd(matcher, r); err != nil {
+		panic(err)
+	}
+}
+
+// TryAdd 添加路由
+//
+// 与 [Group.Add] 相同，但是在出错时返回错误信息，而不是 panic。
+func (g *Group[T]) TryAdd(matcher Matcher, r *Router[T]) error {
+	return g.insert(matcher, r, func(routers []*Router[T]) (int, error) {
+		return slices.IndexFunc(routers, func(rr *Router[T]) bool { return rr.priority < r.priority }), nil
+	})
+}
+
+// AddBefore 将 r 添加到名为 target 的路由之前
+//
+// r 的优先级将被修改为与 target 相同。如果 target 不存在，将触发 panic。
+// 其它参数可参考 [Group.Add]。
+func (g *Group[T]) AddBefore(target string, matcher Matcher, r *Router[T]) {
+	if err := g.TryAddBefore(target, matcher, r); err != nil {
+		panic(err)
+	}
+}
+
+// TryAddBefore 将 r 添加到名为 target 的路由之前
+//
+// 与 [Group.AddBefore] 相同，但是在出错时返回错误信息，而不是 panic。
+func (g *Group[T]) TryAddBefore(target string, matcher Matcher, r *Router[T]) error {
+	return g.insert(matcher, r, func(routers []*Router[T]) (int, error) {
+		index, err := g.index(routers, target)
+		if err != nil {
+			return 0, err
+		}
+		r.priority = routers[index].priority
+		return index, nil
+	})
+}
+
+// AddAfter 将 r 添加到名为 target 的路由之后
+//
+// r 的优先级将被修改为与 target 相同。如果 target 不存在，将触发 panic。
+// 其它参数可参考 [Group.Add]。
+func (g *Group[T]) AddAfter(target string, matcher Matcher, r *Router[T]) {
+	if err := g.TryAddAfter(target, matcher, r); err != nil {
+		panic(err)
+	}
+}
+
+// TryAddAfter 将 r 添加到名为 target 的路由之后
+//
+// 与 [Group.AddAfter] 相同，但是在出错时返回错误信息，而不是 panic。
+func (g *Group[T]) TryAddAfter(target string, matcher Matcher, r *Router[T]) error {
+	return g.insert(matcher, r, func(routers []*Router[T]) (int, error) {
+		index, err := g.index(routers, target)
+		if err != nil {
+			return 0, err
+		}
+		r.priority = routers[index].priority
+		return index + 1, nil
+	})
+}
+
+func (g *Group[T]) index(routers []*Router[T], name string) (int, error) {
+	index := slices.IndexFunc(routers, func(r *Router[T]) bool { return r.Name() == name })
+	if index < 0 {
+		return 0, &types.RouterNotFoundError{Name: name}
+	}
+	return index, nil
+}
+
+// index 返回 r 应该插入的位置，-1 表示插入到最后。
+func (g *Group[T]) insert(matcher Matcher, r *Router[T], index func([]*Router[T]) (int, error)) error {
+	if err := g.insertRouter(matcher, r, index); err != nil {
+		return err
+	}
+	g.notify(types.RouterAdded, r.Name())
+	return nil
+}
+
+func (g *Group[T]) insertRouter(matcher Matcher, r *Router[T], index func([]*Router[T]) (int, error)) error {
 	if matcher == nil {
-		matcher = MatcherFunc(anyRouter)
+		matcher = anyMatcher{}
+	}
+
+	// r.Use 会同步触发观察者，所以只能在释放锁之后为 r 应用中间件，
+	// 再次加锁时如果 g.ms 又有新增的内容，则继续应用新增的部分。
+	var applied int
+	for {
+		ms, err := g.tryInsertRouter(matcher, r, index, applied)
+		if err != nil || len(ms) == 0 {
+			return err
+		}
+		r.Use(ms...)
+		applied += len(ms)
 	}
+}
+
+// 将 r 插入到路由列表中
+//
+// applied 为 g.ms 中已经应用到 r 的中间件数量，如果还有未应用的中间件，
+// 则不会插入 r，而是返回这些中间件。
+func (g *Group[T]) tryInsertRouter(matcher Matcher, r *Router[T], index func([]*Router[T]) (int, error), applied int) ([]types.Middleware[T], error) {
+	g.locker.Lock()
+	defer g.locker.Unlock()
+
+	state := g.state.Load()
 
 	// 重名检测
-	if slices.IndexFunc(g.routers, func(rr *Router[T]) bool { return rr.Name() == r.Name() }) >= 0 {
-		panic(fmt.Sprintf("已经存在名为 %s 的路由", r.Name()))
+	if slices.IndexFunc(state.routers, func(rr *Router[T]) bool { return rr.Name() == r.Name() }) >= 0 {
+		return nil, &types.RouterExistsError{Name: r.Name()}
+	}
+
+	i, err := index(state.routers)
+	if err != nil {
+		return nil, err
+	}
+	if i < 0 {
+		i = len(state.routers)
+	}
+
+	if ms := g.ms[applied:]; len(ms) > 0 {
+		return slices.Clone(ms), nil
 	}
 
-	r.Use(g.ms...)
 	r.matcher = matcher
-	g.routers = append(g.routers, r)
+	r.groupMaintenance = &g.maintenance
+	r.inflight.draining.Store(false)
+	g.state.Store(&groupState[T]{
+		routers:  slices.Insert(slices.Clone(state.routers), i, r),
+		notFound: state.notFound,
+	})
+	return nil, nil
 }
 
 // Router 返回指定名称的路由
 func (g *Group[T]) Router(name string) *Router[T] {
-	for _, r := range g.routers {
+	for _, r := range g.state.Load().routers {
 		if r.Name() == name {
 			return r
 		}
@@ -122,20 +338,59 @@ func (g *Group[T]) Router(name string) *Router[T] {
 }
 
 // Use 为所有已经注册的路由添加中间件
+//
+// NOTE: 如果需要与 [Group.ServeHTTP] 同时调用，各个 [Router] 都应该指定 [WithLock]。
 func (g *Group[T]) Use(m ...types.Middleware[T]) {
-	for _, r := range g.routers {
+	g.locker.Lock()
+	state := g.state.Load()
+	g.state.Store(&groupState[T]{
+		routers:  state.routers,
+		notFound: tree.ApplyMiddleware(state.notFound, "", "", "", m...),
+	})
+	g.ms = append(g.ms, m...) // 之后添加的路由由 insertRouter 应用
+	g.locker.Unlock()
+
+	// r.Use 会同步触发观察者，需要在释放锁之后调用。
+	for _, r := range state.routers {
 		r.Use(m...)
 	}
-
-	g.notFound = tree.ApplyMiddleware(g.notFound, "", "", "", m...)
-	g.ms = append(g.ms, m...)
 }
 
 // Routers 返回路由列表
-func (g *Group[T]) Routers() []*Router[T] { return g.routers }
+//
+// 返回的是当前路由列表的快照，之后对 [Group] 的修改不会影响该返回值，调用方也不应该修改该返回值。
+func (g *Group[T]) Routers() []*Router[T] { return g.state.Load().routers }
 
 func (g *Group[T]) Remove(name string) {
-	g.routers = slices.DeleteFunc(g.routers, func(r *Router[T]) bool { return r.Name() == name })
+	if g.remove(name) != nil {
+		g.notify(types.RouterRemoved, name)
+	}
+}
+
+// 删除名为 name 的路由并返回该路由，不存在则返回 nil。
+func (g *Group[T]) remove(name string) *Router[T] {
+	g.locker.Lock()
+	defer g.locker.Unlock()
+
+	state := g.state.Load()
+	index := slices.IndexFunc(state.routers, func(r *Router[T]) bool { return r.Name() == name })
+	if index < 0 {
+		return nil
+	}
+
+	r := state.routers[index]
+	g.state.Store(&groupState[T]{
+		routers:  slices.Delete(slices.Clone(state.routers), index, index+1),
+		notFound: state.notFound,
+	})
+	return r
+}
+
+// 触发事件，需要在释放锁之后调用。
+func (g *Group[T]) notify(t types.EventType, router string) {
+	for _, o := range g.observers {
+		o(&types.Event{Type: t, Router: router})
+	}
 }
 
 func (g *Group[T]) Routes() map[string]map[string][]string {
@@ -148,3 +403,32 @@ func (g *Group[T]) Routes() map[string]map[string][]string {
 
 	return routes
 }
+
+// Matchers 返回各个路由的匹配条件
+//
+// 键名为路由名称，键值为 [Matcher] 的描述信息，比如：
+//
+//	host=*.example.com AND path-version=v2
+//
+// 如果 [Matcher] 实现了 [fmt.Stringer]，则采用其返回值，否则为类型名称。
+func (g *Group[T]) Matchers() map[string]string {
+	routers := g.Routers()
+
+	ms := make(map[string]string, len(routers))
+	for _, r := range routers {
+		ms[r.Name()] = describeMatcher(r.matcher)
+	}
+	return ms
+}
+
+func (n *allowNode) build() {
+	slices.Sort(n.methods)
+	n.methods = slices.Compact(n.methods)
+	n.allow = strings.Join(n.methods, ", ")
+}
+
+func (n *allowNode) Pattern() string { return n.pattern }
+
+func (n *allowNode) Methods() []string { return n.methods }
+
+func (n *allowNode) AllowHeader() string { return n.allow }
@@ -6,12 +6,18 @@ package mux
 
 import (
 	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/issue9/assert/v4"
 	"github.com/issue9/assert/v4/rest"
 
+	"github.com/issue9/mux/v9/header"
 	"github.com/issue9/mux/v9/internal/tree"
+	"github.com/issue9/mux/v9/types"
 )
 
 func newGroup(a *assert.Assertion, o ...Option) *Group[http.Handler] {
@@ -90,12 +96,23 @@ func TestGroup_Add(t *testing.T) {
 	def = newRouter(a, "host")
 	a.PanicString(func() {
 		g.Add(&pathVersion{}, def)
-	}, "已经存在名为 host 的路由")
+	}, "router host already exists")
 	a.PanicString(func() {
 		g.New("host", &pathVersion{})
-	}, "已经存在名为 host 的路由")
+	}, "router host already exists")
 
 	a.Nil(g.Router("not-exists"))
+
+	// TryAdd
+	a.ErrorString(g.TryAdd(nil, newRouter(a, "host")), "router host already exists")
+	a.NotError(g.TryAdd(nil, newRouter(a, "host-2")))
+	r, err := g.TryNew("host-2", nil)
+	a.ErrorString(err, "router host-2 already exists").Nil(r)
+	r, err = g.TryNew("host-3", nil, WithAllowedCORS(-2))
+	a.Error(err).Nil(r)
+	r, err = g.TryNew("host-3", nil)
+	a.NotError(err).NotNil(r)
+	a.Length(g.Routers(), 3)
 }
 
 func TestGroup_Remove(t *testing.T) {
@@ -246,3 +263,274 @@ func TestGroup_routers_multiple(t *testing.T) {
 	// 指向 v2
 	rest.NewRequest(a, http.MethodGet, "https://example.com/v2/path").Do(g).Status(203)
 }
+
+func TestGroup_fallthrough(t *testing.T) {
+	a := assert.New(t, false)
+
+	// 未指定 WithFallthrough
+	g := newGroup(a)
+	g.New("host", NewHosts(false, "example.com")).Get("/host", rest.BuildHandler(a, 201, "", nil))
+	g.New("def", nil).Get("/def", rest.BuildHandler(a, 202, "", nil))
+	rest.Get(a, "https://example.com/host").Do(g).Status(201)
+	rest.Get(a, "https://example.com/def").Do(g).Status(404)
+
+	g = newGroup(a, WithFallthrough(true))
+	g.Use(tree.BuildTestMiddleware(a, "m1"))
+	host := g.New("host", NewHosts(false, "example.com"))
+	host.Get("/host", rest.BuildHandler(a, 201, "", nil)).
+		Get("/posts", rest.BuildHandler(a, 201, "", nil))
+	def := g.New("def", nil)
+	def.Get("/def", rest.BuildHandler(a, 202, "", nil)).
+		Post("/posts", rest.BuildHandler(a, 202, "", nil)).
+		Delete("/delete", rest.BuildHandler(a, 202, "", nil))
+
+	rest.Get(a, "https://example.com/host").Do(g).Status(201).StringBody("m1")
+	rest.Get(a, "https://example.com/def").Do(g).Status(202).StringBody("m1")
+	rest.Get(a, "https://other.com/def").Do(g).Status(202)
+	rest.Get(a, "https://other.com/host").Do(g).Status(404).StringBody("404 page not found\nm1")
+	rest.Get(a, "https://example.com/not-exists").Do(g).Status(404)
+
+	// 两个 router 都有 /posts
+	rest.Get(a, "https://example.com/posts").Do(g).Status(201)
+	rest.Post(a, "https://example.com/posts", nil).Do(g).Status(202)
+	rest.Delete(a, "https://example.com/posts").Do(g).
+		Status(http.StatusMethodNotAllowed).
+		Header(header.Allow, "GET, HEAD, OPTIONS, POST").
+		StringBody("m1")
+
+	// 只有 def 有 /delete
+	rest.Get(a, "https://example.com/delete").Do(g).
+		Status(http.StatusMethodNotAllowed).
+		Header(header.Allow, "DELETE, OPTIONS")
+}
+
+func TestGroup_pathVersion(t *testing.T) {
+	a := assert.New(t, false)
+
+	g := newGroup(a, WithFallthrough(true))
+	v1 := g.New("v1", NewPathVersion("version", "v1"), WithURLDomain("https://example.com"))
+	v1.Get("/users/{id}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		a.Equal(r.URL.Path, "/v1/users/1").Equal(r.URL.RawPath, "")
+		w.WriteHeader(201)
+	}))
+	def := g.New("def", nil)
+	def.Get("/v1/posts", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		a.Equal(r.URL.Path, "/v1/posts")
+		w.WriteHeader(202)
+	}))
+
+	rest.Get(a, "/v1/users/1").Do(g).Status(201)
+	rest.Get(a, "/v1/posts").Do(g).Status(202) // v1 匹配但找不到路由，不应该影响 def
+
+	u, err := v1.URL(false, "/users/{id}", map[string]string{"id": "1"})
+	a.NotError(err).Equal(u, "https://example.com/v1/users/1")
+}
+
+func TestGroup_notAcceptable(t *testing.T) {
+	a := assert.New(t, false)
+
+	// 未指定 WithNotAcceptable
+	g := newGroup(a)
+	g.New("v1", NewHeaderVersion("version", "", nil, "1.0", "2.0")).Get("/users", rest.BuildHandler(a, 201, "", nil))
+	rest.Get(a, "/users").Header(header.Accept, "application/json; version=3.0").Do(g).Status(http.StatusNotFound)
+
+	g = newGroup(a, WithNotAcceptable(true))
+	g.New("v1", NewHeaderVersion("version", "", nil, "1.0", "2.0")).Get("/users", rest.BuildHandler(a, 201, "", nil))
+	g.New("v3", NewHeaderVersion("version", "", nil, "3.0")).Post("/users", rest.BuildHandler(a, 202, "", nil))
+	rest.Get(a, "/users").Header(header.Accept, "application/json; version=1.0").Do(g).Status(201)
+	rest.Get(a, "/users").Header(header.Accept, "application/json; version=4.0").Do(g).
+		Status(http.StatusNotAcceptable).
+		StringBody("version: 1.0, 2.0")
+	rest.Get(a, "/users").Do(g).Status(http.StatusNotAcceptable) // 没有版本号也没有默认值
+
+	// 其它 Router 匹配了请求，依然返回 404。
+	g.New("def", nil).Get("/posts", rest.BuildHandler(a, 203, "", nil))
+	rest.Get(a, "/users").Header(header.Accept, "application/json; version=4.0").Do(g).Status(http.StatusNotFound)
+	rest.Get(a, "/posts").Header(header.Accept, "application/json; version=4.0").Do(g).Status(203)
+
+	// 405 优先于 406
+	g = newGroup(a, WithNotAcceptable(true), WithFallthrough(true))
+	g.New("v1", NewHeaderVersion("version", "", nil, "1.0")).Get("/users", rest.BuildHandler(a, 201, "", nil))
+	g.New("def", nil).Get("/posts", rest.BuildHandler(a, 203, "", nil))
+	rest.Get(a, "/users").Header(header.Accept, "application/json; version=4.0").Do(g).Status(http.StatusNotAcceptable)
+	rest.Delete(a, "/posts").Header(header.Accept, "application/json; version=4.0").Do(g).Status(http.StatusMethodNotAllowed)
+}
+
+func TestGroup_Matchers(t *testing.T) {
+	a := assert.New(t, false)
+
+	g := newGroup(a)
+	g.New("host", AndMatcher(NewHosts(false, "{sub}.example.com"), OrMatcher(NewPathVersion("", "v1"), NewHeaderVersion("", "", nil, "2"))))
+	g.New("canary", NewCanary(10, nil))
+	g.New("func", MatcherFunc(func(*http.Request, *types.Context) bool { return true }))
+	g.New("def", nil)
+
+	a.Equal(g.Matchers(), map[string]string{
+		"host":   "host={sub}.example.com AND (path-version=v1 OR header-version=2)",
+		"canary": "canary=10%",
+		"func":   "mux.MatcherFunc",
+		"def":    "*",
+	})
+
+	// Hosts 不参与 Router.URL 的生成
+	r := g.New("tenant", NewHosts(false, "{sub}.example.com"))
+	u, err := r.URL(false, "/static", nil)
+	a.NotError(err).Equal(u, "/static")
+
+	r = g.New("domain", NewHosts(false, "example.com"), WithURLDomain("https://example.com"))
+	u, err = r.URL(false, "/x", nil)
+	a.NotError(err).Equal(u, "https://example.com/x")
+}
+
+func TestGroup_priority(t *testing.T) {
+	a := assert.New(t, false)
+	g := newGroup(a)
+
+	names := func() []string {
+		ret := make([]string, 0, len(g.Routers()))
+		for _, r := range g.Routers() {
+			ret = append(ret, r.Name())
+		}
+		return ret
+	}
+
+	g.New("r1", nil)
+	g.New("r2", nil, WithPriority(10))
+	g.New("r3", nil)
+	g.New("r4", nil, WithPriority(-1))
+	g.New("r5", nil, WithPriority(10))
+	a.Equal(names(), []string{"r2", "r5", "r1", "r3", "r4"})
+
+	g.AddBefore("r1", nil, newRouter(a, "b1"))
+	g.AddAfter("r5", nil, newRouter(a, "a5", WithPriority(100)))
+	a.Equal(names(), []string{"r2", "r5", "a5", "b1", "r1", "r3", "r4"})
+	a.Equal(g.Router("a5").priority, 10)
+
+	a.PanicString(func() {
+		g.AddAfter("not-exists", nil, newRouter(a, "a6"))
+	}, "router not-exists does not exist")
+	a.PanicString(func() {
+		g.AddBefore("r1", nil, newRouter(a, "r2"))
+	}, "router r2 already exists")
+	a.Error(g.TryAddBefore("not-exists", nil, newRouter(a, "b6"))).
+		Error(g.TryAddAfter("r1", nil, newRouter(a, "r2"))).
+		NotError(g.TryAddAfter("r4", nil, newRouter(a, "a4"))).
+		Equal(names(), []string{"r2", "r5", "a5", "b1", "r1", "r3", "r4", "a4"})
+	g.Remove("a4")
+
+	// 前一个 Routers 返回的快照不受影响
+	routers := g.Routers()
+	g.Remove("r2")
+	a.Equal(routers[0].Name(), "r2").
+		Equal(names(), []string{"r5", "a5", "b1", "r1", "r3", "r4"})
+}
+
+func TestGroup_concurrent(t *testing.T) {
+	a := assert.New(t, false)
+	g := newGroup(a, WithLock(true))
+	g.New("def", nil).Get("/path", rest.BuildHandler(a, 201, "", nil))
+
+	var wg sync.WaitGroup
+	for i := range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			name := "r" + strconv.Itoa(i)
+			for range 50 {
+				g.AddBefore("def", NewHosts(false, name+".example.com"), newRouter(a, name))
+				g.Use(types.MiddlewareFunc[http.Handler](func(next http.Handler, _, _, _ string) http.Handler { return next }))
+				g.Remove(name)
+			}
+		}()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 50 {
+				w := httptest.NewRecorder()
+				g.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/path", nil))
+				a.Equal(w.Code, 201)
+			}
+		}()
+	}
+	wg.Wait()
+	a.Length(g.Routers(), 1)
+
+	// 未指定 WithLock，仅修改路由列表。
+	g = newGroup(a)
+	g.New("def", nil).Get("/path", rest.BuildHandler(a, 201, "", nil))
+	for i := range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			name := "r" + strconv.Itoa(i)
+			for range 50 {
+				g.AddBefore("def", NewHosts(false, name+".example.com"), newRouter(a, name))
+				g.Remove(name)
+			}
+		}()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 50 {
+				w := httptest.NewRecorder()
+				g.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/path", nil))
+				a.Equal(w.Code, 201)
+			}
+		}()
+	}
+	wg.Wait()
+	a.Length(g.Routers(), 1)
+}
+
+func TestGroup_observer(t *testing.T) {
+	a := assert.New(t, false)
+
+	events := make([]*types.Event, 0, 10)
+	g := newGroup(a, WithObserver(func(e *types.Event) { events = append(events, e) }))
+
+	r1 := g.New("r1", nil)
+	r1.Get("/users", rest.BuildHandler(a, 201, "", nil)) // 选项会传递给 r1
+	g.Add(nil, newRouter(a, "r2"))
+	a.Error(g.TryAdd(nil, newRouter(a, "r2"))) // 出错不触发
+	g.Remove("r1")
+	g.Remove("not-exists")
+
+	a.Length(events, 4).
+		Equal(events[0], &types.Event{Type: types.RouterAdded, Router: "r1"}).
+		Equal(events[1], &types.Event{Type: types.RouteAdded, Router: "r1", Pattern: "/users", Methods: []string{http.MethodGet}}).
+		Equal(events[2], &types.Event{Type: types.RouterAdded, Router: "r2"}).
+		Equal(events[3], &types.Event{Type: types.RouterRemoved, Router: "r1"})
+}
+
+// 观察者中可以再次调用 Group 的方法
+func TestGroup_observer_reentrant(t *testing.T) {
+	a := assert.New(t, false)
+
+	var g *Group[http.Handler]
+	var applied int
+	g = newGroup(a, WithObserver(func(e *types.Event) {
+		if e.Type == types.MiddlewareApplied {
+			applied++
+			g.Remove("not-exists")
+			a.NotNil(g.Routers())
+		}
+	}))
+	g.Use(tree.BuildTestMiddleware(a, "m1"))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		g.New("r1", nil).Get("/users", rest.BuildHandler(a, 201, "", nil))
+		g.Use(tree.BuildTestMiddleware(a, "m2"))
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		a.TB().Fatal("观察者中调用 Group 的方法发生死锁")
+	}
+
+	a.Equal(applied, 2)
+	rest.Get(a, "/users").Do(g).Status(201).StringBody("m1m2")
+}
@@ -0,0 +1,130 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+package header
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Item 带有质量因子的报头元素
+//
+// 诸如 Accept、Accept-Language 等报头，由多个以逗号分隔的 Item 组成：
+//
+//	Accept: text/html;level=1;q=0.8, application/json
+type Item struct {
+	Value  string            // 元素的值，比如 text/html
+	Q      float64           // 质量因子，即 q 参数的值，未指定时为 1
+	Params map[string]string // 除 q 之外的其它参数，键名为小写
+}
+
+// ParseQHeader 解析带有质量因子的报头
+//
+// 返回的元素按 [Item.Q] 从大到小排序，相同 Q 值的元素，
+// 更具体的元素排在前面（比如 text/html 在 text/* 之前），其它保持原有的顺序。
+// 无法解析的 q 值将被当作 0 处理。
+func ParseQHeader(h string) []*Item {
+	h = strings.TrimSpace(h)
+	if h == "" {
+		return nil
+	}
+
+	items := make([]*Item, 0, strings.Count(h, ",")+1)
+	for v := range strings.SplitSeq(h, ",") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+
+		item := &Item{Q: 1}
+		for i, p := range strings.Split(v, ";") {
+			p = strings.TrimSpace(p)
+			if i == 0 {
+				item.Value = strings.ToLower(p)
+				continue
+			}
+
+			key, val, _ := strings.Cut(p, "=")
+			key = strings.ToLower(strings.TrimSpace(key))
+			val = strings.Trim(strings.TrimSpace(val), `"`)
+			if key == "" {
+				continue
+			}
+
+			if key == "q" {
+				q, err := strconv.ParseFloat(val, 64)
+				if err != nil || q < 0 {
+					q = 0
+				}
+				item.Q = min(q, 1)
+				continue
+			}
+
+			if item.Params == nil {
+				item.Params = make(map[string]string, 2)
+			}
+			item.Params[key] = val
+		}
+
+		if item.Value != "" {
+			items = append(items, item)
+		}
+	}
+
+	slices.SortStableFunc(items, func(a, b *Item) int {
+		switch {
+		case a.Q > b.Q:
+			return -1
+		case a.Q < b.Q:
+			return 1
+		default:
+			return specificity(b.Value) - specificity(a.Value)
+		}
+	})
+
+	return items
+}
+
+// 媒体类型的具体程度，值越大越具体。
+func specificity(v string) int {
+	switch {
+	case v == "*" || v == "*/*":
+		return 0
+	case strings.HasSuffix(v, "/*"):
+		return 1
+	default:
+		return 2
+	}
+}
+
+// MatchMediaType 判断媒体类型 mt 是否符合 pattern 的要求
+//
+// pattern 可以是 */*、text/* 或是 text/html 等格式，比较时不区分大小写，忽略参数部分。
+func MatchMediaType(pattern, mt string) bool {
+	pattern = trimParams(pattern)
+	mt = trimParams(mt)
+
+	switch {
+	case pattern == "*/*" || pattern == "*":
+		return true
+	case strings.HasSuffix(pattern, "/*"):
+		return strings.HasPrefix(mt, pattern[:len(pattern)-1])
+	default:
+		return pattern == mt
+	}
+}
+
+func trimParams(v string) string {
+	if i := strings.IndexByte(v, ';'); i >= 0 {
+		v = v[:i]
+	}
+	return strings.ToLower(strings.TrimSpace(v))
+}
+
+// Specificity 返回媒体类型 pattern 的具体程度
+//
+// */* 为 0，text/* 为 1，其它为 2。
+func Specificity(pattern string) int { return specificity(trimParams(pattern)) }
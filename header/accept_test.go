@@ -0,0 +1,47 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+package header
+
+import (
+	"testing"
+
+	"github.com/issue9/assert/v4"
+)
+
+func TestParseQHeader(t *testing.T) {
+	a := assert.New(t, false)
+
+	a.Empty(ParseQHeader(""))
+	a.Empty(ParseQHeader(" , "))
+
+	items := ParseQHeader("text/*;q=0.8, application/json;charset=utf-8, */*;q=0.1, text/html;q=0.8, xml;q=x")
+	a.Length(items, 5).
+		Equal(items[0].Value, "application/json").Equal(items[0].Q, 1.0).Equal(items[0].Params, map[string]string{"charset": "utf-8"}).
+		Equal(items[1].Value, "text/html").Equal(items[1].Q, 0.8).
+		Equal(items[2].Value, "text/*").Equal(items[2].Q, 0.8).
+		Equal(items[3].Value, "*/*").Equal(items[3].Q, 0.1).
+		Equal(items[4].Value, "xml").Equal(items[4].Q, 0.0)
+
+	items = ParseQHeader("zh-CN, en;q=0.5, zh;q=0.9")
+	a.Length(items, 3).
+		Equal(items[0].Value, "zh-cn").
+		Equal(items[1].Value, "zh").
+		Equal(items[2].Value, "en")
+}
+
+func TestMatchMediaType(t *testing.T) {
+	a := assert.New(t, false)
+
+	a.True(MatchMediaType("*/*", "text/html")).
+		True(MatchMediaType("*", "text/html")).
+		True(MatchMediaType("text/*", "text/html")).
+		True(MatchMediaType("TEXT/html", "text/html; charset=utf-8")).
+		False(MatchMediaType("text/*", "application/json")).
+		False(MatchMediaType("text/plain", "text/html"))
+
+	a.Equal(Specificity("*/*"), 0).
+		Equal(Specificity("text/*"), 1).
+		Equal(Specificity("text/html;q=1"), 2)
+}
@@ -123,7 +123,9 @@ const (
 	ServerTiming     = "Server-Timing"
 	Signature        = "Signature"
 	SignedHeaders    = "Signed-Headers"
-	SourceMap        = "SourceMap" // 连接到源代码映射，以便调试器可以逐步执行原始源代码，而不是生成或转换后的代码。
+	SourceMap        = "SourceMap"   // 连接到源代码映射，以便调试器可以逐步执行原始源代码，而不是生成或转换后的代码。
+	Deprecation      = "Deprecation" // 表示资源已经或是将要被弃用，格式为 @ 加上 Unix 时间戳。	Deprecation: @1688169599
+	Sunset           = "Sunset"      // 表示资源将在指定的时间之后不可用。	Sunset: Sun, 30 Jun 2024 23:59:59 GMT
 
 	// 点击劫持保护：
 	//
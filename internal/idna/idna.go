@@ -0,0 +1,158 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+// Package idna 国际化域名的简单实现
+//
+// 仅实现了将域名转换为 punycode 的功能，不包含 IDNA2008 中的映射和校验规则。
+package idna
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+// 以下常量源自 https://www.rfc-editor.org/rfc/rfc3492#section-5
+const (
+	base        = 36
+	tMin        = 1
+	tMax        = 26
+	skew        = 38
+	damp        = 700
+	initialBias = 72
+	initialN    = 128
+
+	acePrefix = "xn--"
+)
+
+var errOverflow = errors.New("idna: overflow")
+
+// ToASCII 将域名转换为 ASCII 格式
+//
+// 每个包含非 ASCII 字符的标签都将被转换为以 xn-- 开头的 punycode 格式，
+// 其它的标签仅转换为小写。
+func ToASCII(domain string) (string, error) {
+	if isASCII(domain) {
+		return strings.ToLower(domain), nil
+	}
+
+	labels := strings.Split(domain, ".")
+	for i, l := range labels {
+		l = strings.ToLower(l)
+		if !isASCII(l) {
+			p, err := encode(l)
+			if err != nil {
+				return "", err
+			}
+			l = acePrefix + p
+		}
+		labels[i] = l
+	}
+	return strings.Join(labels, "."), nil
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// 源自 https://www.rfc-editor.org/rfc/rfc3492#section-6.3
+func encode(s string) (string, error) {
+	runes := []rune(s)
+
+	out := make([]byte, 0, len(s))
+	for _, r := range runes {
+		if r < utf8.RuneSelf {
+			out = append(out, byte(r))
+		}
+	}
+	b := len(out)
+	h := b
+	if b > 0 {
+		out = append(out, '-')
+	}
+
+	n, delta, bias := rune(initialN), 0, initialBias
+	for h < len(runes) {
+		m := rune(utf8.MaxRune)
+		for _, r := range runes {
+			if r >= n && r < m {
+				m = r
+			}
+		}
+
+		d := int(m-n) * (h + 1)
+		if d < 0 || delta > (1<<31-1)-d {
+			return "", errOverflow
+		}
+		delta += d
+		n = m
+
+		for _, r := range runes {
+			if r < n {
+				delta++
+				if delta < 0 {
+					return "", errOverflow
+				}
+				continue
+			}
+			if r > n {
+				continue
+			}
+
+			q := delta
+			for k := base; ; k += base {
+				t := k - bias
+				switch {
+				case t < tMin:
+					t = tMin
+				case t > tMax:
+					t = tMax
+				}
+				if q < t {
+					break
+				}
+				out = append(out, digit(t+(q-t)%(base-t)))
+				q = (q - t) / (base - t)
+			}
+			out = append(out, digit(q))
+
+			bias = adapt(delta, h+1, h == b)
+			delta = 0
+			h++
+		}
+
+		delta++
+		n++
+	}
+
+	return string(out), nil
+}
+
+func digit(d int) byte {
+	if d < 26 {
+		return byte('a' + d)
+	}
+	return byte('0' + d - 26)
+}
+
+func adapt(delta, numPoints int, first bool) int {
+	if first {
+		delta /= damp
+	} else {
+		delta /= 2
+	}
+	delta += delta / numPoints
+
+	k := 0
+	for delta > ((base-tMin)*tMax)/2 {
+		delta /= base - tMin
+		k += base
+	}
+	return k + (base-tMin+1)*delta/(delta+skew)
+}
@@ -0,0 +1,33 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+package idna
+
+import (
+	"testing"
+
+	"github.com/issue9/assert/v4"
+)
+
+func TestToASCII(t *testing.T) {
+	a := assert.New(t, false)
+
+	data := []struct {
+		input, output string
+	}{
+		{input: "example.com", output: "example.com"},
+		{input: "Example.COM", output: "example.com"},
+		{input: "中文.com", output: "xn--fiq228c.com"},
+		{input: "bücher.example", output: "xn--bcher-kva.example"},
+		{input: "Bücher.example", output: "xn--bcher-kva.example"},
+		{input: "münchen.de", output: "xn--mnchen-3ya.de"},
+		{input: "api.例子.测试", output: "api.xn--fsqu00a.xn--0zwm56d"},
+		{input: "☃-⌘.com", output: "xn----dqo34k.com"},
+	}
+
+	for _, item := range data {
+		out, err := ToASCII(item.input)
+		a.NotError(err).Equal(out, item.output, item.input)
+	}
+}
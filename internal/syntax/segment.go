@@ -5,7 +5,6 @@
 package syntax
 
 import (
-	"fmt"
 	"math"
 	"regexp"
 	"strings"
@@ -53,7 +52,7 @@ type Segment struct {
 // 如果为非字符串类型的内容，应该是以 { 符号开头才是合法的。
 func (i *Interceptors) NewSegment(val string) (*Segment, error) {
 	if len(val) > math.MaxInt16 {
-		return nil, fmt.Errorf("单个节点的长度不能超过 %d", math.MaxInt16)
+		return nil, &types.SyntaxError{Pattern: val, Offset: math.MaxInt16, Reason: types.SyntaxTooLong}
 	}
 
 	seg := &Segment{Value: val, Type: String}
@@ -67,7 +66,7 @@ func (i *Interceptors) NewSegment(val string) (*Segment, error) {
 	separator := strings.IndexByte(val, separatorByte)
 	if start > end || start+1 == end || // }{ 或是  {}
 		(separator > 0 && start+1 == separator) { // {:rule}
-		return nil, fmt.Errorf("无效的语法：%s", val)
+		return nil, &types.SyntaxError{Pattern: val, Offset: start, Reason: types.SyntaxInvalid}
 	}
 
 	if separator == -1 || separator+1 == end || separator > end { // {name} 或是 {name:} 或是 {name}:
@@ -107,7 +106,7 @@ func (i *Interceptors) NewSegment(val string) (*Segment, error) {
 	}
 	expr, err := regexp.Compile("(?" + name + seg.rule + ")" + seg.Suffix)
 	if err != nil {
-		return nil, err
+		return nil, &types.SyntaxError{Pattern: val, Offset: separator + 1, Reason: types.SyntaxInvalidRegexp, Err: err}
 	}
 	seg.expr = expr
 	seg.calcAmbiguousLength()
@@ -174,11 +173,11 @@ func (seg *Segment) Similarity(s1 *Segment) int {
 func (seg *Segment) Split(i *Interceptors, pos int) ([]*Segment, error) {
 	s1, err := i.NewSegment(seg.Value[:pos])
 	if err != nil {
-		return nil, err
+		return nil, offsetError(err, seg.Value, 0)
 	}
 	s2, err := i.NewSegment(seg.Value[pos:])
 	if err != nil {
-		return nil, err
+		return nil, offsetError(err, seg.Value, pos)
 	}
 	return []*Segment{s1, s2}, nil
 }
@@ -7,10 +7,11 @@ package syntax
 
 import (
 	"errors"
-	"fmt"
 	"strings"
 
 	"github.com/issue9/errwrap"
+
+	"github.com/issue9/mux/v9/types"
 )
 
 // Type 路由项节点的类型
@@ -85,7 +86,7 @@ func (i *Interceptors) URL(buf *errwrap.StringBuilder, pattern string, ps map[st
 
 		val, found := ps[seg.Name]
 		if !found {
-			return fmt.Errorf("未找到参数 %s 的值", seg.Name)
+			return &types.ParamMismatchError{Pattern: pattern, Param: seg.Name, Missing: true}
 		}
 		buf.WString(val).WString(seg.Suffix)
 	}
@@ -102,38 +103,51 @@ func (i *Interceptors) URL(buf *errwrap.StringBuilder, pattern string, ps map[st
 //	/posts/{year}/{id}.html ==> /posts/, {year}/, {id}.html
 func (i *Interceptors) Split(str string) ([]*Segment, error) {
 	if str == "" {
-		return nil, errors.New("参数 str 不能为空")
+		return nil, &types.SyntaxError{Reason: types.SyntaxEmpty}
 	}
 
 	ss := splitString(str)
 	segs := make([]*Segment, 0, len(ss))
 	var lastFlag bool
+	var offset int // s 在 str 中的起始位置
 	names := make(map[string]int, len(ss))
 
 	for _, s := range ss {
 		if lastFlag && s[0] == startByte {
-			return nil, fmt.Errorf("两个命名参数不能连续出现：%s", str)
+			return nil, &types.SyntaxError{Pattern: str, Offset: offset, Reason: types.SyntaxAdjacentParams}
 		}
 		lastFlag = s[len(s)-1] == endByte
 
 		seg, err := i.NewSegment(s)
 		if err != nil {
-			return nil, err
+			return nil, offsetError(err, str, offset)
 		}
 
 		if seg.Type != String {
 			if names[seg.Name] > 0 {
-				return nil, fmt.Errorf("存在相同名称的路由参数：%s", seg.Name)
+				return nil, &types.SyntaxError{Pattern: str, Offset: offset, Reason: types.SyntaxDuplicateParam}
 			}
 			names[seg.Name]++
 		}
 
 		segs = append(segs, seg)
+		offset += len(s)
 	}
 
 	return segs, nil
 }
 
+// 将 [types.SyntaxError] 中的位置信息修正为相对于 pattern 的值
+//
+// offset 为出错的片段在 pattern 中的起始位置。
+func offsetError(err error, pattern string, offset int) error {
+	var serr *types.SyntaxError
+	if errors.As(err, &serr) {
+		return &types.SyntaxError{Pattern: pattern, Offset: serr.Offset + offset, Reason: serr.Reason, Err: serr.Err}
+	}
+	return err
+}
+
 func splitString(str string) []string {
 	ss := make([]string, 0, strings.Count(str, string(startByte))+1)
 
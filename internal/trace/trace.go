@@ -5,24 +5,46 @@
 package trace
 
 import (
-	"html"
 	"net/http"
 	"net/http/httputil"
+	"strconv"
 
 	"github.com/issue9/mux/v9/header"
 )
 
+// Redacted 默认不会原样返回的报头
+var Redacted = []string{header.Authorization, header.ProxyAuthorization, header.Cookie}
+
 // Trace 简单的 Trace 请求方法实现
 //
+// 按 RFC9110 的要求，以 message/http 的形式原样返回请求内容，但是不包含 [Redacted] 和 redact 中指定的报头。
+// 如果 Max-Forwards 报头的值不是有效的非负整数，返回 400。
+//
 // NOTE: 并不是百分百原样返回，具体可参考 [httputil.DumpRequest] 的说明。
-// 如果内容包含特殊的 HTML 字符会被 [html.EscapeString] 转码。
-func Trace(w http.ResponseWriter, r *http.Request, body bool) error {
-	text, err := httputil.DumpRequest(r, body)
-	if err == nil {
-		w.WriteHeader(http.StatusOK)
-		w.Header().Set(header.ContentType, header.MessageHTTP)
-		_, err = w.Write([]byte(html.EscapeString(string(text))))
+func Trace(w http.ResponseWriter, r *http.Request, body bool, redact ...string) error {
+	if mf := r.Header.Get(header.MaxForwards); mf != "" {
+		if n, err := strconv.Atoi(mf); err != nil || n < 0 {
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return nil
+		}
+	}
+
+	rr := r.Clone(r.Context())
+	for _, h := range Redacted {
+		rr.Header.Del(h)
+	}
+	for _, h := range redact {
+		rr.Header.Del(h)
+	}
+
+	text, err := httputil.DumpRequest(rr, body)
+	if err != nil {
+		return err
 	}
 
+	w.Header().Set(header.ContentType, header.MessageHTTP)
+	w.Header().Set(header.XContentTypeOptions, "nosniff")
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write(text)
 	return err
 }
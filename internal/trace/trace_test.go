@@ -26,13 +26,48 @@ func TestTrace(t *testing.T) {
 	a.Contains(body, "/path").
 		NotContains(body, "body").
 		True(strings.HasPrefix(body, http.MethodTrace)).
-		Equal(w.Header().Get(header.ContentType), header.MessageHTTP)
+		Equal(w.Header().Get(header.ContentType), header.MessageHTTP).
+		Equal(w.Header().Get(header.XContentTypeOptions), "nosniff")
 
 	w = httptest.NewRecorder()
 	a.NotError(Trace(w, r, true))
 	body = w.Body.String()
 	a.Contains(body, "/path").
-		Contains(body, "&lt;body&gt;").
+		Contains(body, "<body>"). // 不转码
 		True(strings.HasPrefix(body, http.MethodTrace)).
 		Equal(w.Header().Get(header.ContentType), header.MessageHTTP)
+
+	// 报头过滤
+	r = rest.NewRequest(a, http.MethodTrace, "/path").
+		Header(header.Authorization, "Bearer token").
+		Header(header.Cookie, "sid=1").
+		Header(header.ProxyAuthorization, "Basic xx").
+		Header("X-Secret", "secret").
+		Header("X-Public", "public").
+		Request()
+	w = httptest.NewRecorder()
+	a.NotError(Trace(w, r, false, "x-secret"))
+	body = w.Body.String()
+	a.NotContains(body, "Bearer").
+		NotContains(body, "sid=1").
+		NotContains(body, "Basic").
+		NotContains(body, "secret").
+		Contains(body, "X-Public: public")
+	a.Equal(r.Header.Get(header.Authorization), "Bearer token") // 不修改原始请求
+
+	// Max-Forwards
+	r = rest.NewRequest(a, http.MethodTrace, "/path").Header(header.MaxForwards, "0").Request()
+	w = httptest.NewRecorder()
+	a.NotError(Trace(w, r, false))
+	a.Equal(w.Code, http.StatusOK).Contains(w.Body.String(), "Max-Forwards: 0")
+
+	r = rest.NewRequest(a, http.MethodTrace, "/path").Header(header.MaxForwards, "-1").Request()
+	w = httptest.NewRecorder()
+	a.NotError(Trace(w, r, false))
+	a.Equal(w.Code, http.StatusBadRequest)
+
+	r = rest.NewRequest(a, http.MethodTrace, "/path").Header(header.MaxForwards, "abc").Request()
+	w = httptest.NewRecorder()
+	a.NotError(Trace(w, r, false))
+	a.Equal(w.Code, http.StatusBadRequest)
 }
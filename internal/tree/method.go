@@ -5,7 +5,6 @@
 package tree
 
 import (
-	"fmt"
 	"net/http"
 	"slices"
 	"strings"
@@ -72,41 +71,74 @@ func (n *node[T]) buildMethods() {
 	for method := range n.handlers {
 		n.methodIndex += methodIndexMap[method]
 	}
-	if n.root.hasTrace {
+	if _, found := n.handlers[http.MethodTrace]; !found && n.root.traceable(n.Pattern()) {
 		n.methodIndex += methodIndexMap[http.MethodTrace]
 	}
 	buildMethodIndexes(n.methodIndex)
 }
 
+// 重新计算 n 及其子节点的请求方法
+func (n *node[T]) rebuildMethods() {
+	if len(n.handlers) > 0 && n.parent != nil {
+		n.buildMethods()
+	}
+	for _, c := range n.children {
+		c.rebuildMethods()
+	}
+}
+
 func (n *node[T]) AllowHeader() string { return methodIndexes[n.methodIndex].options }
 
 // Methods 当前节点支持的请求方法
 func (n *node[T]) Methods() []string { return methodIndexes[n.methodIndex].methods }
 
 // 添加一个处理函数
+//
+// OPTIONS 和 HEAD 可以手动添加，手动添加的会覆盖自动生成的处理函数。
 func (n *node[T]) addMethods(h T, pattern string, ms []types.Middleware[T], methods ...string) error {
 	for _, m := range methods {
-		if m == http.MethodOptions || m == http.MethodHead || (n.root.hasTrace && m == http.MethodTrace) {
-			return fmt.Errorf("无法手动添加 OPTIONS/HEAD/TRACE 请求方法")
+		if m == http.MethodTrace && n.root.traceable(pattern) {
+			return &types.UnsupportedMethodError{Pattern: pattern, Method: m}
 		}
 		if _, found := methodIndexMap[m]; !found {
-			return fmt.Errorf("该请求方法 %s 不被支持", m)
+			return &types.UnsupportedMethodError{Pattern: pattern, Method: m}
 		}
 
-		if _, found := n.handlers[m]; found {
-			return fmt.Errorf("该请求方法 %s 已经存在", m)
+		if _, found := n.handlers[m]; found && (!isAutoMethod(m) || n.isCustom(m)) {
+			return &types.MethodExistsError{Pattern: pattern, Method: m}
+		}
+	}
+
+	for _, m := range methods {
+		hh := ApplyMiddleware(h, m, pattern, n.root.Name(), ms...)
+
+		if isAutoMethod(m) {
+			if auto, found := n.handlers[m]; found { // 保存自动生成的处理函数，以便在删除时恢复。
+				n.setDefault(m, auto)
+			}
+			n.customs |= methodIndexMap[m]
+			n.handlers[m] = hh
+			delete(n.variants, m) // 由 GET 生成的内容协商对象
+			continue
 		}
 
 		if m == http.MethodGet {
-			n.handlers[http.MethodHead] = ApplyMiddleware(h, http.MethodHead, pattern, n.root.Name(), ms...)
+			head := ApplyMiddleware(h, http.MethodHead, pattern, n.root.Name(), ms...)
+			if n.isCustom(http.MethodHead) {
+				n.setDefault(http.MethodHead, head)
+			} else {
+				n.handlers[http.MethodHead] = head
+			}
 		}
 
-		n.handlers[m] = ApplyMiddleware(h, m, pattern, n.root.Name(), ms...)
+		n.handlers[m] = hh
 	}
 
 	// 查看是否需要添加 OPTIONS
 	if _, found := n.handlers[http.MethodOptions]; !found {
 		n.handlers[http.MethodOptions] = ApplyMiddleware(n.root.optionsBuilder(n), http.MethodOptions, pattern, n.root.Name(), ms...)
+	} else if _, found := n.defaults[http.MethodOptions]; !found && n.isCustom(http.MethodOptions) {
+		n.setDefault(http.MethodOptions, ApplyMiddleware(n.root.optionsBuilder(n), http.MethodOptions, pattern, n.root.Name(), ms...))
 	}
 
 	if _, found := n.handlers[methodNotAllowed]; !found {
@@ -119,6 +151,33 @@ func (n *node[T]) addMethods(h T, pattern string, ms []types.Middleware[T], meth
 	return nil
 }
 
+// 是否为可以自动生成处理函数的请求方法
+func isAutoMethod(m string) bool { return m == http.MethodOptions || m == http.MethodHead }
+
+// 请求方法 m 是否为手动添加的
+func (n *node[T]) isCustom(m string) bool {
+	i := methodIndexMap[m]
+	return n.customs&i == i
+}
+
+func (n *node[T]) setDefault(m string, h T) {
+	if n.defaults == nil {
+		n.defaults = make(map[string]T, 2)
+	}
+	n.defaults[m] = h
+}
+
+// 删除手动添加的 OPTIONS 或是 HEAD，如果存在自动生成的处理函数，则恢复该函数。
+func (n *node[T]) removeCustom(m string) {
+	n.customs &^= methodIndexMap[m]
+	if h, found := n.defaults[m]; found {
+		n.handlers[m] = h
+		delete(n.defaults, m)
+	} else {
+		delete(n.handlers, m)
+	}
+}
+
 // num 表示为该请求方法加上的计数
 func (tree *Tree[T]) buildMethods(num int, methods ...string) {
 	for _, m := range methods {
@@ -127,15 +186,14 @@ func (tree *Tree[T]) buildMethods(num int, methods ...string) {
 
 	// 即使所有接口都没了，也有 OPTIONS * 存在，所以始终有 OPTIONS 和可能的 TRACE 存在。
 	tree.node.methodIndex = methodIndexMap[http.MethodOptions]
-	if tree.hasTrace {
-		tree.node.methodIndex += methodIndexMap[http.MethodTrace]
-	}
-
 	for m, num := range tree.methods {
 		if num > 0 {
 			tree.node.methodIndex += methodIndexMap[m]
 		}
 	}
+	if tree.traceAll() {
+		tree.node.methodIndex |= methodIndexMap[http.MethodTrace]
+	}
 
 	buildMethodIndexes(tree.node.methodIndex)
 }
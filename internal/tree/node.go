@@ -25,6 +25,9 @@ type node[T any] struct {
 
 	methodIndex int // 在 methodIndexes 中的索引值
 	handlers    map[string]T
+	variants    map[string][]*Variant[T] // 需要内容协商的处理对象，键名为请求方法。
+	customs     int                      // 手动添加的 OPTIONS 和 HEAD，值的计算方式与 methodIndex 相同。
+	defaults    map[string]T             // 被手动添加的 OPTIONS 和 HEAD 覆盖的自动生成的处理函数
 
 	// 保存着 node 实例在 children 中的下标。
 	//
@@ -156,18 +159,20 @@ func (n *node[T]) find(pattern string) *node[T] {
 	return nil
 }
 
-// 清除路由项
-func (n *node[T]) clean(prefix string) {
+// 清除以 prefix 开头的路由项，返回是否有节点被清除。
+func (n *node[T]) clean(prefix string) bool {
 	if len(prefix) == 0 {
+		cleaned := len(n.children) > 0
 		n.children = n.children[:0]
-		return
+		return cleaned
 	}
 
+	var cleaned bool
 	dels := make([]string, 0, len(n.children))
 	for _, child := range n.children {
 		if len(child.segment.Value) < len(prefix) {
-			if strings.HasPrefix(prefix, child.segment.Value) {
-				child.clean(prefix[len(child.segment.Value):])
+			if strings.HasPrefix(prefix, child.segment.Value) && child.clean(prefix[len(child.segment.Value):]) {
+				cleaned = true
 			}
 		}
 
@@ -180,6 +185,7 @@ func (n *node[T]) clean(prefix string) {
 		n.children = removeNodes(n.children, del)
 	}
 	n.buildIndexes()
+	return cleaned || len(dels) > 0
 }
 
 // 从子节点中查找与当前路径匹配的节点，若找不到，则返回 nil。
@@ -261,16 +267,14 @@ func splitNode[T any](n *node[T], pos int) (*node[T], error) {
 		return nil, err
 	}
 	ret := p.newChild(segs[0])
-	c := ret.newChild(segs[1])
-	c.handlers = n.handlers
-	c.methodIndex = n.methodIndex
-	c.children = n.children
-	c.indexes = n.indexes
-	for _, item := range c.children {
-		item.parent = c
-	}
 
-	// ret 和 c 的内容在 newChild 之后被修改，所以需要对其子元素重新排序。
+	// 直接将 n 作为后一段的节点，而不是复制其内容，
+	// 因为 OPTIONS 和 405 等处理对象中保存着 n 的引用。
+	n.segment = segs[1]
+	n.parent = ret
+	ret.children = append(ret.children, n)
+
+	// ret 和 n 的内容在 newChild 之后被修改，所以需要对其子元素重新排序。
 	ret.sort()
 	p.sort()
 
@@ -336,6 +340,16 @@ func (n *node[T]) applyMiddleware(ms ...types.Middleware[T]) {
 		n.handlers[m] = ApplyMiddleware(h, m, n.Pattern(), n.root.Name(), ms...)
 	}
 
+	for m, h := range n.defaults {
+		n.defaults[m] = ApplyMiddleware(h, m, n.Pattern(), n.root.Name(), ms...)
+	}
+
+	for m, vs := range n.variants {
+		for _, v := range vs {
+			v.Handler = ApplyMiddleware(v.Handler, m, n.Pattern(), n.root.Name(), ms...)
+		}
+	}
+
 	for _, c := range n.children {
 		c.applyMiddleware(ms...)
 	}
@@ -6,8 +6,9 @@
 package tree
 
 import (
-	"fmt"
 	"net/http"
+	"slices"
+	"strings"
 	"sync"
 
 	"github.com/issue9/errwrap"
@@ -47,6 +48,8 @@ type Tree[T any] struct {
 	name                                    string
 	notFound, trace                         T
 	hasTrace                                bool
+	traces                                  map[string]bool // 按前缀启用或禁用 TRACE，未指定的路径默认启用。
+	observer                                types.Observer
 	optionsBuilder, methodNotAllowedBuilder types.BuildNodeHandler[T]
 }
 
@@ -89,6 +92,7 @@ func New[T any](
 	tree.node.handlers = map[string]T{
 		http.MethodOptions: tree.optionsBuilder(tree.node),
 	}
+	tree.buildMethods(0)
 
 	if lock {
 		tree.locker = &sync.RWMutex{}
@@ -99,10 +103,93 @@ func New[T any](
 
 func (tree *Tree[T]) Name() string { return tree.name }
 
+// SetObserver 指定路由项变更事件的观察者
+//
+// 需要在添加路由项之前调用。
+func (tree *Tree[T]) SetObserver(o types.Observer) { tree.observer = o }
+
+// 触发事件，需要在释放锁之后调用。
+func (tree *Tree[T]) notify(t types.EventType, pattern string, methods []string) {
+	if tree.observer != nil {
+		tree.observer(&types.Event{Type: t, Router: tree.Name(), Pattern: pattern, Methods: methods})
+	}
+}
+
+// NotFound 返回 404 的处理对象
+func (tree *Tree[T]) NotFound() T {
+	if tree.locker != nil {
+		tree.locker.RLock()
+		defer tree.locker.RUnlock()
+	}
+	return tree.notFound
+}
+
+// SetTrace 以 prefix 开头的路径是否可以处理 TRACE 请求
+//
+// 仅在 [New] 中指定了 trace 参数时有效，存在多个符合要求的前缀时，以最长的前缀为准。
+func (tree *Tree[T]) SetTrace(prefix string, enable bool) {
+	if !tree.hasTrace {
+		return
+	}
+
+	if tree.locker != nil {
+		tree.locker.Lock()
+		defer tree.locker.Unlock()
+	}
+
+	if tree.traces == nil {
+		tree.traces = make(map[string]bool, 5)
+	}
+	tree.traces[prefix] = enable
+
+	tree.buildMethods(0)
+	tree.node.rebuildMethods()
+}
+
+// path 是否可以处理 TRACE 请求
+func (tree *Tree[T]) traceable(path string) bool {
+	if !tree.hasTrace {
+		return false
+	}
+
+	enable, size := true, -1
+	for p, v := range tree.traces {
+		if len(p) > size && strings.HasPrefix(path, p) {
+			enable, size = v, len(p)
+		}
+	}
+	return enable
+}
+
+// 是否所有的路径都可以处理 TRACE 请求，用于 OPTIONS * 请求。
+func (tree *Tree[T]) traceAll() bool {
+	if !tree.hasTrace {
+		return false
+	}
+
+	for _, v := range tree.traces {
+		if !v {
+			return false
+		}
+	}
+	return true
+}
+
 // Add 添加路由项
 //
 // methods 可以为空，表示采用 [AnyMethods] 中的值。
 func (tree *Tree[T]) Add(pattern string, h T, ms []types.Middleware[T], methods ...string) error {
+	if len(methods) == 0 {
+		methods = AnyMethods
+	}
+	if err := tree.add(pattern, h, ms, methods...); err != nil {
+		return err
+	}
+	tree.notify(types.RouteAdded, pattern, methods)
+	return nil
+}
+
+func (tree *Tree[T]) add(pattern string, h T, ms []types.Middleware[T], methods ...string) error {
 	if err := tree.checkAmbiguous(pattern); err != nil {
 		return err
 	}
@@ -121,9 +208,6 @@ func (tree *Tree[T]) Add(pattern string, h T, ms []types.Middleware[T], methods
 		n.handlers = make(map[string]T, handlersSize)
 	}
 
-	if len(methods) == 0 {
-		methods = AnyMethods
-	}
 	return n.addMethods(h, pattern, ms, methods...)
 }
 
@@ -142,23 +226,43 @@ func (tree *Tree[T]) checkAmbiguous(pattern string) error {
 		s = n.segment.Value + s
 		n = n.parent
 	}
-	return fmt.Errorf("%s 与已有的节点 %s 存在歧义", pattern, s)
+	return &types.AmbiguousError{Pattern: pattern, Existing: s}
 }
 
 // Clean 清除路由项
+//
+// 只有在确实清除了路由项时才会触发 [types.PrefixCleaned] 事件。
 func (tree *Tree[T]) Clean(prefix string) {
+	if tree.clean(prefix) {
+		tree.notify(types.PrefixCleaned, prefix, nil)
+	}
+}
+
+// 返回是否有节点被清除
+func (tree *Tree[T]) clean(prefix string) bool {
 	if tree.locker != nil {
 		tree.locker.Lock()
 		defer tree.locker.Unlock()
 	}
 
-	tree.node.clean(prefix)
+	return tree.node.clean(prefix)
 }
 
 // Remove 移除路由项
 //
-// methods 可以为空，表示删除所有内容。单独删除 OPTIONS，将不会发生任何事情。
+// methods 可以为空，表示删除所有内容。
+// 单独删除自动生成的 OPTIONS，将不会发生任何事情，删除手动添加的 OPTIONS 和 HEAD，会恢复为自动生成的处理函数。
 func (tree *Tree[T]) Remove(pattern string, methods ...string) {
+	if removed := tree.remove(pattern, methods...); len(removed) > 0 {
+		tree.notify(types.RouteRemoved, pattern, removed)
+	}
+}
+
+// 返回被删除的请求方法
+//
+// 由节点在删除前后的请求方法比较得出，不包含未注册的以及删除之后依然存在的请求方法，
+// 比如自动生成的 OPTIONS。
+func (tree *Tree[T]) remove(pattern string, methods ...string) []string {
 	if tree.locker != nil {
 		tree.locker.Lock()
 		defer tree.locker.Unlock()
@@ -166,20 +270,41 @@ func (tree *Tree[T]) Remove(pattern string, methods ...string) {
 
 	child := tree.Find(pattern)
 	if child == nil {
-		return
+		return nil
 	}
 
+	before := child.Methods()
+
 	if len(methods) == 0 {
 		child.handlers = nil
+		child.variants = nil
+		child.customs = 0
+		child.defaults = nil
 	} else {
 		for _, m := range methods {
 			switch m {
-			case http.MethodOptions: // OPTIONS 不作任何操作
+			case http.MethodOptions: // 自动生成的 OPTIONS 不作任何操作
+				if child.isCustom(m) {
+					child.removeCustom(m)
+				}
+			case http.MethodHead:
+				if child.isCustom(m) {
+					child.removeCustom(m)
+				} else {
+					delete(child.handlers, m)
+				}
+				delete(child.variants, m)
 			case http.MethodGet:
-				delete(child.handlers, http.MethodHead)
+				if child.isCustom(http.MethodHead) {
+					delete(child.defaults, http.MethodHead)
+				} else {
+					delete(child.handlers, http.MethodHead)
+				}
+				delete(child.variants, http.MethodHead)
 				fallthrough
 			default:
 				delete(child.handlers, m)
+				delete(child.variants, m)
 			}
 		}
 
@@ -189,11 +314,15 @@ func (tree *Tree[T]) Remove(pattern string, methods ...string) {
 			if e1 && e2 {
 				delete(child.handlers, http.MethodOptions)
 				delete(child.handlers, methodNotAllowed)
+				child.customs = 0
+				child.defaults = nil
 			}
 		}
 	}
 
 	child.buildMethods()
+	after := child.Methods()
+	removed := slices.DeleteFunc(slices.Clone(before), func(m string) bool { return slices.Contains(after, m) })
 
 	for child.size() == 0 && len(child.children) == 0 {
 		child.parent.children = removeNodes(child.parent.children, child.segment.Value)
@@ -202,6 +331,7 @@ func (tree *Tree[T]) Remove(pattern string, methods ...string) {
 	}
 
 	tree.buildMethods(-1, methods...)
+	return removed
 }
 
 // 获取指定的节点，若节点不存在，则在该位置生成一个新节点。
@@ -213,22 +343,18 @@ func (tree *Tree[T]) getNode(pattern string) (*node[T], error) {
 	return tree.node.getNode(segs)
 }
 
-// 此方法主要用于将 locker 的使用范围减至最小。
-func (tree *Tree[T]) match(ctx *types.Context) *node[T] {
-	if tree.locker != nil {
-		tree.locker.RLock()
-		defer tree.locker.RUnlock()
-	}
-	return tree.node.matchChildren(ctx)
-}
-
 // Handler 查找与参数匹配的处理对象
 //
 // 如果未找到，也会返回相应在的处理对象，比如 tree.notFound 或是相应的 methodNotAllowed 方法。
 func (tree *Tree[T]) Handler(ctx *types.Context, method string) (types.Node, T, bool) {
 	ctx.SetRouterName(tree.Name())
 
-	if tree.hasTrace && method == http.MethodTrace {
+	if tree.locker != nil {
+		tree.locker.RLock()
+		defer tree.locker.RUnlock()
+	}
+
+	if method == http.MethodTrace && tree.traceable(ctx.Path) {
 		return tree.node, tree.trace, true
 	}
 
@@ -236,7 +362,7 @@ func (tree *Tree[T]) Handler(ctx *types.Context, method string) (types.Node, T,
 	if ctx.Path == "*" || ctx.Path == "" {
 		node = tree.node
 	} else {
-		node = tree.match(ctx)
+		node = tree.node.matchChildren(ctx)
 	}
 
 	if node == nil || node.size() == 0 {
@@ -248,6 +374,41 @@ func (tree *Tree[T]) Handler(ctx *types.Context, method string) (types.Node, T,
 	return node, node.handlers[methodNotAllowed], false
 }
 
+// Match 查找与 ctx.Path 匹配的节点
+//
+// 与 [Tree.Handler] 不同，不区分请求方法，未找到时返回 nil。
+func (tree *Tree[T]) Match(ctx *types.Context) types.Node {
+	if tree.locker != nil {
+		tree.locker.RLock()
+		defer tree.locker.RUnlock()
+	}
+
+	if ctx.Path == "*" || ctx.Path == "" {
+		return nil
+	}
+
+	if n := tree.node.matchChildren(ctx); n != nil && n.size() > 0 {
+		return n
+	}
+	return nil
+}
+
+// Params 返回 pattern 中的参数名称
+func (tree *Tree[T]) Params(pattern string) ([]string, error) {
+	segs, err := tree.interceptors.Split(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(segs))
+	for _, seg := range segs {
+		if seg.Type != syntax.String {
+			names = append(names, seg.Name)
+		}
+	}
+	return names, nil
+}
+
 // Routes 获取当前的所有路由项以及对应的请求方法
 func (tree *Tree[T]) Routes() map[string][]string {
 	if tree.locker != nil {
@@ -258,7 +419,7 @@ func (tree *Tree[T]) Routes() map[string][]string {
 	routes := make(map[string][]string, 100)
 
 	ms := []string{http.MethodOptions}
-	if tree.hasTrace {
+	if tree.traceAll() {
 		ms = append(ms, http.MethodTrace)
 	}
 	routes["*"] = ms
@@ -279,7 +440,7 @@ func (tree *Tree[T]) Find(pattern string) *node[T] { return tree.node.find(patte
 func (tree *Tree[T]) URL(buf *errwrap.StringBuilder, pattern string, ps map[string]string) error {
 	n := tree.Find(pattern)
 	if n == nil {
-		return fmt.Errorf("%s 并不是一条有效的注册路由项", pattern)
+		return &types.RouteNotFoundError{Pattern: pattern}
 	}
 
 	nodes := make([]*node[T], 0, 5)
@@ -299,10 +460,10 @@ func (tree *Tree[T]) URL(buf *errwrap.StringBuilder, pattern string, ps map[stri
 		case syntax.Named, syntax.Regexp:
 			param, exists := ps[s.Name]
 			if !exists {
-				return fmt.Errorf("未找到参数 %s 的值", s.Name)
+				return &types.ParamMismatchError{Pattern: pattern, Param: s.Name, Missing: true}
 			}
 			if !s.Valid(param) {
-				return fmt.Errorf("参数 %s 格式不匹配", s.Name)
+				return &types.ParamMismatchError{Pattern: pattern, Param: s.Name, Value: param}
 			}
 
 			buf.WString(param).WString(s.Suffix)
@@ -314,6 +475,20 @@ func (tree *Tree[T]) URL(buf *errwrap.StringBuilder, pattern string, ps map[stri
 
 // ApplyMiddleware 为已有的路由项添加中间件
 func (tree *Tree[T]) ApplyMiddleware(ms ...types.Middleware[T]) {
+	if len(ms) == 0 {
+		return
+	}
+
+	tree.applyMiddleware(ms...)
+	tree.notify(types.MiddlewareApplied, "", nil)
+}
+
+func (tree *Tree[T]) applyMiddleware(ms ...types.Middleware[T]) {
+	if tree.locker != nil {
+		tree.locker.Lock()
+		defer tree.locker.Unlock()
+	}
+
 	tree.notFound = ApplyMiddleware(tree.notFound, "", "", tree.Name(), ms...)
 	if tree.hasTrace {
 		tree.trace = ApplyMiddleware(tree.trace, http.MethodTrace, "", tree.Name(), ms...)
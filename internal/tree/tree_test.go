@@ -5,6 +5,7 @@
 package tree
 
 import (
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -49,7 +50,9 @@ func (t *tester) add(method, pattern string, code int) {
 func (t *tester) addAmbiguous(pattern string) {
 	t.a.TB().Helper()
 	b := rest.BuildHandler(t.a, http.StatusOK, "", nil)
-	t.a.ErrorString(t.tree.Add(pattern, b, nil, http.MethodGet), pattern+" 与已有的节点")
+	err := t.tree.Add(pattern, b, nil, http.MethodGet)
+	var aerr *types.AmbiguousError
+	t.a.True(errors.As(err, &aerr)).Equal(aerr.Pattern, pattern)
 }
 
 // 验证按照指定的 method 和 path 访问，是否会返回相同的 code 值，
@@ -461,8 +464,15 @@ func TestTree_Add_Remove(t *testing.T) {
 	tree.Remove("/path", http.MethodOptions) // remove options 不发生任何操作
 	a.Equal(tree.node.find("/path").AllowHeader(), "DELETE, OPTIONS")
 
-	a.ErrorString(tree.Add("/path/{id}/path/{id:\\d+}", rest.BuildHandler(a, 1, "", nil), nil, http.MethodHead), "存在相同名称的路由参数")
-	a.ErrorString(tree.Add("/path/{id}{id2:\\d+}", rest.BuildHandler(a, 1, "", nil), nil, http.MethodHead), "两个命名参数不能连续出现")
+	var serr *types.SyntaxError
+	err := tree.Add("/path/{id}/path/{id:\\d+}", rest.BuildHandler(a, 1, "", nil), nil, http.MethodHead)
+	a.True(errors.As(err, &serr)).
+		Equal(serr.Reason, types.SyntaxDuplicateParam).
+		Equal(serr.Offset, 16)
+	err = tree.Add("/path/{id}{id2:\\d+}", rest.BuildHandler(a, 1, "", nil), nil, http.MethodHead)
+	a.True(errors.As(err, &serr)).
+		Equal(serr.Reason, types.SyntaxAdjacentParams).
+		Equal(serr.Offset, 10)
 
 	// 多层节点的删除
 
@@ -524,6 +534,17 @@ func TestTree_Routes(t *testing.T) {
 			"/posts/{id}":        {http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodTrace},
 			"/posts/{id}/author": {http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace},
 		})
+
+		tree.SetTrace("", false)
+		tree.SetTrace("/posts/", true)
+		routes = tree.Routes()
+		a.Equal(routes, map[string][]string{
+			"*":                  {http.MethodOptions},
+			"/":                  {http.MethodGet, http.MethodHead, http.MethodOptions},
+			"/posts":             {http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPost},
+			"/posts/{id}":        {http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodTrace},
+			"/posts/{id}/author": {http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace},
+		})
 	})
 }
 
@@ -566,10 +587,10 @@ func TestTree_URL(t *testing.T) {
 	test.urlTrue("/posts/{id}", map[string]string{"id": "100.htm"}, "/posts/100.htm")
 	test.urlTrue("/posts/{id}/author/{action}/", map[string]string{"id": "100.htm", "action": "p"}, "/posts/100.htm/author/p/")
 
-	test.urlFalse("", nil, "并不是一条有效的注册路由项")
-	test.urlFalse("/not-exists", nil, "并不是一条有效的注册路由项")
-	test.urlFalse("/posts/{id}", map[string]string{"other": "other"}, "未找到参数")
-	test.urlFalse("/posts/{id:\\d+}", map[string]string{"id": "xyz"}, "格式不匹配")
+	test.urlFalse("", nil, "is not a registered route")
+	test.urlFalse("/not-exists", nil, "is not a registered route")
+	test.urlFalse("/posts/{id}", map[string]string{"other": "other"}, "missing param id")
+	test.urlFalse("/posts/{id:\\d+}", map[string]string{"id": "xyz"}, "does not match")
 }
 
 func TestTree_match(t *testing.T) {
@@ -0,0 +1,173 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+package tree
+
+import (
+	"net/http"
+
+	"github.com/issue9/mux/v9/header"
+	"github.com/issue9/mux/v9/types"
+)
+
+// Variant 同一路由项中根据媒体类型区分的处理对象
+type Variant[T any] struct {
+	// Produces 可以输出的媒体类型，对应请求报头中的 Accept。
+	//
+	// 为空表示不限制，但是其优先级会低于明确指定了媒体类型的对象。
+	Produces []string
+
+	// Consumes 可以接收的媒体类型，对应请求报头中的 Content-Type。
+	//
+	// 为空表示不限制。
+	Consumes []string
+
+	Handler T
+}
+
+// AddVariants 添加需要进行内容协商的路由项
+//
+// 与 [Tree.Add] 相同，但是同一请求方法下可以有多个处理对象，
+// 在请求时根据 Accept 和 Content-Type 报头选择最合适的一个。
+func (tree *Tree[T]) AddVariants(pattern string, vs []*Variant[T], ms []types.Middleware[T], methods ...string) error {
+	if len(vs) == 0 {
+		return &types.InvalidValueError{Name: "vs", Value: vs}
+	}
+
+	if len(methods) == 0 {
+		methods = AnyMethods
+	}
+	if err := tree.add(pattern, vs[0].Handler, ms, methods...); err != nil {
+		return err
+	}
+	tree.addVariants(pattern, vs, ms, methods...)
+	tree.notify(types.RouteAdded, pattern, methods)
+	return nil
+}
+
+func (tree *Tree[T]) addVariants(pattern string, vs []*Variant[T], ms []types.Middleware[T], methods ...string) {
+	if tree.locker != nil {
+		tree.locker.Lock()
+		defer tree.locker.Unlock()
+	}
+
+	n := tree.Find(pattern)
+	if n.variants == nil {
+		n.variants = make(map[string][]*Variant[T], len(methods))
+	}
+
+	for _, m := range methods {
+		n.variants[m] = buildVariants(vs, m, pattern, tree.Name(), ms)
+		if m == http.MethodGet && !n.isCustom(http.MethodHead) {
+			n.variants[http.MethodHead] = buildVariants(vs, http.MethodHead, pattern, tree.Name(), ms)
+		}
+	}
+}
+
+func buildVariants[T any](vs []*Variant[T], method, pattern, router string, ms []types.Middleware[T]) []*Variant[T] {
+	ret := make([]*Variant[T], 0, len(vs))
+	for _, v := range vs {
+		ret = append(ret, &Variant[T]{
+			Produces: v.Produces,
+			Consumes: v.Consumes,
+			Handler:  ApplyMiddleware(v.Handler, method, pattern, router, ms...),
+		})
+	}
+	return ret
+}
+
+// Negotiate 根据 accept 和 contentType 从节点 n 中选择最合适的处理对象
+//
+// 如果节点 n 的 method 不存在内容协商，则原样返回 h，且 negotiated 为 false；
+// 返回的 status 为 0 表示正常，否则为 [http.StatusNotAcceptable] 或是 [http.StatusUnsupportedMediaType]。
+func (tree *Tree[T]) Negotiate(n types.Node, method string, h T, accept, contentType string) (handler T, status int, negotiated bool) {
+	nn, ok := n.(*node[T])
+	if !ok {
+		return h, 0, false
+	}
+
+	if tree.locker != nil {
+		tree.locker.RLock()
+		defer tree.locker.RUnlock()
+	}
+
+	vs := nn.variants[method]
+	if len(vs) == 0 {
+		return h, 0, false
+	}
+
+	if contentType != "" {
+		vs = consumable(vs, contentType)
+		if len(vs) == 0 {
+			return handler, http.StatusUnsupportedMediaType, true
+		}
+	}
+
+	items := header.ParseQHeader(accept)
+	if len(items) == 0 {
+		return vs[0].Handler, 0, true
+	}
+
+	var curr *Variant[T]
+	var q float64
+	for _, v := range vs {
+		if len(v.Produces) == 0 { // 不限制，仅在没有其它匹配项时才使用。
+			if curr == nil {
+				curr = v
+			}
+			continue
+		}
+
+		for _, p := range v.Produces {
+			if qq := quality(items, p); qq > q {
+				q = qq
+				curr = v
+			}
+		}
+	}
+
+	if curr == nil {
+		return handler, http.StatusNotAcceptable, true
+	}
+	return curr.Handler, 0, true
+}
+
+// 过滤出可以处理 contentType 的对象
+func consumable[T any](vs []*Variant[T], contentType string) []*Variant[T] {
+	ret := make([]*Variant[T], 0, len(vs))
+LOOP:
+	for _, v := range vs {
+		if len(v.Consumes) == 0 {
+			ret = append(ret, v)
+			continue
+		}
+
+		for _, c := range v.Consumes {
+			if header.MatchMediaType(c, contentType) {
+				ret = append(ret, v)
+				continue LOOP
+			}
+		}
+	}
+	return ret
+}
+
+// 获取 mt 在 items 中的质量因子
+//
+// 多个元素与 mt 匹配时，以最具体的那一个为准。
+func quality(items []*header.Item, mt string) float64 {
+	var q float64
+	s := -1
+	for _, item := range items {
+		if !header.MatchMediaType(item.Value, mt) {
+			continue
+		}
+
+		if ss := header.Specificity(item.Value); ss > s {
+			s = ss
+			q = item.Q
+		}
+	}
+	return q
+}
@@ -0,0 +1,103 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+package tree
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/issue9/assert/v4"
+	"github.com/issue9/assert/v4/rest"
+
+	"github.com/issue9/mux/v9/internal/syntax"
+	"github.com/issue9/mux/v9/types"
+)
+
+func TestTree_AddVariants(t *testing.T) {
+	a := assert.New(t, false)
+	tree := NewTestTree(a, false, nil, syntax.NewInterceptors())
+
+	var verr *types.InvalidValueError
+	a.True(errors.As(tree.AddVariants("/path", nil, nil, http.MethodGet), &verr)).Equal(verr.Name, "vs")
+
+	vs := []*Variant[http.Handler]{
+		{Produces: []string{"application/json"}, Handler: rest.BuildHandler(a, 201, "", nil)},
+		{Produces: []string{"text/html", "application/xhtml+xml"}, Consumes: []string{"text/*"}, Handler: rest.BuildHandler(a, 202, "", nil)},
+	}
+	a.NotError(tree.AddVariants("/path", vs, nil, http.MethodGet, http.MethodPost))
+	a.Error(tree.AddVariants("/path", vs, nil, http.MethodGet)) // 已经存在
+
+	n := tree.Find("/path")
+	a.NotNil(n).
+		Length(n.variants, 3). // GET、HEAD 和 POST
+		Length(n.variants[http.MethodGet], 2)
+
+	tree.Remove("/path", http.MethodGet)
+	a.Length(n.variants, 1)
+	tree.Remove("/path")
+	a.Nil(tree.Find("/path"))
+
+	// 手动添加的 HEAD 不参与内容协商
+	a.NotError(tree.Add("/head", rest.BuildHandler(a, 203, "", nil), nil, http.MethodHead))
+	a.NotError(tree.AddVariants("/head", vs, nil, http.MethodGet))
+	n = tree.Find("/head")
+	a.Length(n.variants, 1).True(n.isCustom(http.MethodHead)).NotNil(n.defaults[http.MethodHead])
+}
+
+func TestTree_Negotiate(t *testing.T) {
+	a := assert.New(t, false)
+	tree := NewTestTree(a, false, nil, syntax.NewInterceptors())
+
+	a.NotError(tree.Add("/plain", rest.BuildHandler(a, 200, "", nil), nil, http.MethodGet))
+	vs := []*Variant[http.Handler]{
+		{Produces: []string{"application/json"}, Handler: rest.BuildHandler(a, 201, "", nil)},
+		{Produces: []string{"text/html"}, Consumes: []string{"text/*"}, Handler: rest.BuildHandler(a, 202, "", nil)},
+		{Handler: rest.BuildHandler(a, 203, "", nil)},
+	}
+	a.NotError(tree.AddVariants("/path", vs, nil, http.MethodGet, http.MethodPost))
+
+	status := func(path, method, accept, contentType string) int {
+		a.TB().Helper()
+
+		ctx := types.NewContext()
+		ctx.Path = path
+		n, h, ok := tree.Handler(ctx, method)
+		a.True(ok)
+
+		h, s, _ := tree.Negotiate(n, method, h, accept, contentType)
+		if s != 0 {
+			return s
+		}
+		w := rest.NewRequest(a, method, path).Do(h)
+		return w.Resp().StatusCode
+	}
+
+	// 不需要内容协商的路由
+	a.Equal(status("/plain", http.MethodGet, "application/json", ""), 200)
+
+	a.Equal(status("/path", http.MethodGet, "", ""), 201)
+	a.Equal(status("/path", http.MethodHead, "", ""), 201)
+	a.Equal(status("/path", http.MethodGet, "application/json", ""), 201)
+	a.Equal(status("/path", http.MethodGet, "text/html;q=0.9,application/json;q=0.8", ""), 202)
+	a.Equal(status("/path", http.MethodGet, "text/*;q=0.9,application/json;q=0.8", ""), 202)
+	a.Equal(status("/path", http.MethodGet, "text/*;q=0.9,text/html;q=0.1,application/json;q=0.8", ""), 201)
+	a.Equal(status("/path", http.MethodGet, "image/png", ""), 203) // 不限制的项
+
+	a.Equal(status("/path", http.MethodPost, "application/json", "text/plain"), 201)
+	a.Equal(status("/path", http.MethodPost, "text/html", "text/plain"), 202)
+	a.Equal(status("/path", http.MethodPost, "text/html", "image/png"), 203) // 202 无法处理 image/png
+
+	tree = NewTestTree(a, false, nil, syntax.NewInterceptors())
+	a.NotError(tree.AddVariants("/path", vs[:2], nil, http.MethodPost))
+	a.Equal(status("/path", http.MethodPost, "image/png", ""), http.StatusNotAcceptable)
+	a.Equal(status("/path", http.MethodPost, "application/json;q=0", ""), http.StatusNotAcceptable)
+	a.Equal(status("/path", http.MethodPost, "", "image/png"), 201) // 201 不限制 Consumes
+	a.Equal(status("/path", http.MethodPost, "text/html", "image/png"), http.StatusNotAcceptable)
+
+	tree = NewTestTree(a, false, nil, syntax.NewInterceptors())
+	a.NotError(tree.AddVariants("/path", vs[1:2], nil, http.MethodPost))
+	a.Equal(status("/path", http.MethodPost, "", "image/png"), http.StatusUnsupportedMediaType)
+}
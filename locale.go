@@ -0,0 +1,161 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+package mux
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"github.com/issue9/mux/v9/header"
+	"github.com/issue9/mux/v9/types"
+)
+
+// Locale 匹配路径中语言标签的 [Matcher] 实现
+//
+// 以路径的第一段作为语言标签，比如 /zh-CN/path 和 /en/path，
+// 匹配成功之后会将 [types.Context.Path] 中的语言标签部分去掉，与 [NewPathVersion] 相同。
+//
+// 同时实现了 [URLBuilder]，[Router.URL] 生成的地址会带上语言标签。
+type Locale struct {
+	paramName string
+	tags      []string
+	def       atomic.Pointer[string] // 可在运行时通过 SetDefault 修改
+}
+
+// NewLocale 声明 [Locale] 实例
+//
+// param 将语言标签作为参数保存到上下文中时的名称，如果不需要保存参数，可以设置为空值；
+// tag 允许的语言标签，比如 zh-CN、en 等，匹配时不区分大小写，保存到上下文中的是 tag 中的原始值；
+func NewLocale(param string, tag ...string) *Locale {
+	for _, t := range tag {
+		if t == "" || strings.ContainsRune(t, '/') {
+			panic(&types.InvalidValueError{Name: "tag", Value: t})
+		}
+	}
+
+	l := &Locale{paramName: param, tags: slices.Clone(tag)}
+	if len(tag) > 0 {
+		l.def.Store(&l.tags[0])
+	}
+	return l
+}
+
+// SetDefault 指定默认的语言标签
+//
+// 当无法从 Accept-Language 中找到合适的语言时采用此值，默认为 tag 中的第一个元素。
+// def 必须存在于 tag 之中，否则会 panic。
+func (l *Locale) SetDefault(def string) {
+	t, found := l.find(def)
+	if !found {
+		panic(&types.InvalidValueError{Name: "def", Value: def})
+	}
+	l.def.Store(&t)
+}
+
+func (l *Locale) defaultTag() string {
+	if def := l.def.Load(); def != nil {
+		return *def
+	}
+	return ""
+}
+
+// Tags 返回所有的语言标签
+func (l *Locale) Tags() []string { return slices.Clone(l.tags) }
+
+// String 返回语言标签的描述信息，比如 locale=zh-CN,en
+func (l *Locale) String() string { return "locale=" + strings.Join(l.tags, ",") }
+
+func (l *Locale) Match(_ *http.Request, ctx *types.Context) bool {
+	p := strings.TrimPrefix(ctx.Path, "/")
+	seg, rest, found := strings.Cut(p, "/")
+
+	tag, ok := l.find(seg)
+	if !ok {
+		return false
+	}
+
+	if found {
+		ctx.Path = "/" + rest
+	} else {
+		ctx.Path = "/"
+	}
+	if l.paramName != "" {
+		ctx.Set(l.paramName, tag)
+	}
+	return true
+}
+
+func (l *Locale) find(tag string) (string, bool) {
+	for _, t := range l.tags {
+		if strings.EqualFold(t, tag) {
+			return t, true
+		}
+	}
+	return "", false
+}
+
+// BuildURL 生成语言标签的路径部分
+//
+// 如果 params 中包含名为 param 的值，则采用该值，否则采用默认值。
+func (l *Locale) BuildURL(params map[string]string) (string, error) {
+	if l.paramName != "" {
+		if t, found := l.find(params[l.paramName]); found {
+			return "/" + t, nil
+		}
+	}
+
+	def := l.defaultTag()
+	if def == "" {
+		return "", nil
+	}
+	return "/" + def, nil
+}
+
+// Negotiate 根据 Accept-Language 报头选择最合适的语言标签
+//
+// 按质量因子从高到低依次查找，zh 可以匹配 zh-CN，zh-CN 也可以匹配 zh，
+// 都不匹配时返回默认值。
+func (l *Locale) Negotiate(r *http.Request) string {
+	for _, item := range header.ParseQHeader(r.Header.Get(header.AcceptLanguage)) {
+		if item.Q <= 0 {
+			continue
+		}
+
+		if item.Value == "*" {
+			return l.defaultTag()
+		}
+
+		if t, found := l.find(item.Value); found {
+			return t
+		}
+
+		for _, t := range l.tags {
+			lt := strings.ToLower(t)
+			if strings.HasPrefix(lt, item.Value+"-") || strings.HasPrefix(item.Value, lt+"-") {
+				return t
+			}
+		}
+	}
+
+	return l.defaultTag()
+}
+
+// Redirect 将不带语言标签的请求重定向到由 [Locale.Negotiate] 选定的语言
+//
+// 比如 /path 会被重定向到 /zh-CN/path，查询参数会被保留。
+// 可作为 [Group] 的 notFound 处理函数的一部分，或是在未带语言标签的 [Router] 中使用。
+//
+// status 为重定向的状态码，一般为 [http.StatusFound] 或是 [http.StatusTemporaryRedirect]。
+func (l *Locale) Redirect(w http.ResponseWriter, r *http.Request, status int) {
+	u := "/" + l.Negotiate(r) + r.URL.EscapedPath()
+	if r.URL.RawQuery != "" {
+		u += "?" + r.URL.RawQuery
+	}
+
+	w.Header().Add(header.Vary, header.AcceptLanguage)
+	http.Redirect(w, r, u, status)
+}
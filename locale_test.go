@@ -0,0 +1,151 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+package mux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/issue9/assert/v4"
+	"github.com/issue9/assert/v4/rest"
+
+	"github.com/issue9/mux/v9/header"
+	"github.com/issue9/mux/v9/types"
+)
+
+var (
+	_ Matcher    = &Locale{}
+	_ URLBuilder = &Locale{}
+)
+
+func TestNewLocale(t *testing.T) {
+	a := assert.New(t, false)
+
+	a.PanicValue(func() { NewLocale("lang", "zh-CN", "") }, &types.InvalidValueError{Name: "tag", Value: ""})
+	a.PanicValue(func() { NewLocale("lang", "zh/CN") }, &types.InvalidValueError{Name: "tag", Value: "zh/CN"})
+
+	l := NewLocale("lang", "zh-CN", "en")
+	a.Equal(l.Tags(), []string{"zh-CN", "en"}).Equal(l.defaultTag(), "zh-CN")
+	l.Tags()[0] = "fr" // 不影响内部的值
+	a.Equal(l.Tags(), []string{"zh-CN", "en"})
+
+	l.SetDefault("EN")
+	a.Equal(l.defaultTag(), "en")
+	a.PanicValue(func() { l.SetDefault("fr") }, &types.InvalidValueError{Name: "def", Value: "fr"})
+
+	a.Empty(NewLocale("lang").defaultTag())
+
+	// 在处理请求的同时修改默认值
+	var wg sync.WaitGroup
+	for i := range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if i%2 == 0 {
+				l.SetDefault("zh-cn")
+			} else {
+				a.Contains([]string{"zh-CN", "en"}, l.Negotiate(rest.Get(a, "/").Request()))
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestLocale_Match(t *testing.T) {
+	a := assert.New(t, false)
+	l := NewLocale("lang", "zh-CN", "en")
+
+	match := func(path, tag, p string) {
+		a.TB().Helper()
+
+		r := rest.Get(a, path).Request()
+		ctx := types.NewContext()
+		ctx.Path = r.URL.Path
+		ok := l.Match(r, ctx)
+		a.Equal(ok, tag != "", path).Equal(r.URL.Path, path)
+		if ok {
+			a.Equal(ctx.MustString("lang", ""), tag).Equal(ctx.Path, p)
+		}
+	}
+
+	match("/zh-CN/path", "zh-CN", "/path")
+	match("/zh-cn/path", "zh-CN", "/path")
+	match("/en/", "en", "/")
+	match("/en", "en", "/")
+	match("/english/path", "", "")
+	match("/fr/path", "", "")
+	match("/path", "", "")
+	match("/", "", "")
+}
+
+func TestLocale_BuildURL(t *testing.T) {
+	a := assert.New(t, false)
+
+	l := NewLocale("lang", "zh-CN", "en")
+	u, err := l.BuildURL(nil)
+	a.NotError(err).Equal(u, "/zh-CN")
+
+	u, err = l.BuildURL(map[string]string{"lang": "en"})
+	a.NotError(err).Equal(u, "/en")
+
+	u, err = l.BuildURL(map[string]string{"lang": "fr"})
+	a.NotError(err).Equal(u, "/zh-CN")
+
+	l = NewLocale("")
+	u, err = l.BuildURL(map[string]string{"lang": "en"})
+	a.NotError(err).Empty(u)
+
+	// Router.URL
+	g := newGroup(a)
+	r := g.New("locale", NewLocale("lang", "zh-CN", "en"), WithURLDomain("https://example.com"))
+	u, err = r.URL(true, "/posts/{id}", map[string]string{"id": "1", "lang": "en"})
+	a.Error(err).Empty(u) // 路由项不存在
+	r.Get("/posts/{id}", rest.BuildHandler(a, 201, "", nil))
+	u, err = r.URL(true, "/posts/{id}", map[string]string{"id": "1", "lang": "en"})
+	a.NotError(err).Equal(u, "https://example.com/en/posts/1")
+}
+
+func TestLocale_Negotiate(t *testing.T) {
+	a := assert.New(t, false)
+	l := NewLocale("lang", "zh-CN", "en", "zh-TW")
+
+	negotiate := func(accept, tag string) {
+		a.TB().Helper()
+		r := rest.Get(a, "/").Header(header.AcceptLanguage, accept).Request()
+		a.Equal(l.Negotiate(r), tag, accept)
+	}
+
+	negotiate("", "zh-CN")
+	negotiate("en", "en")
+	negotiate("en-US,en;q=0.9", "en")
+	negotiate("zh-tw", "zh-TW")
+	negotiate("zh;q=0.5,en;q=0.8", "en")
+	negotiate("fr,zh", "zh-CN")
+	negotiate("fr, *;q=0.1", "zh-CN")
+	negotiate("fr", "zh-CN")
+	negotiate("en;q=0,zh-TW;q=0.1", "zh-TW")
+}
+
+func TestLocale_Redirect(t *testing.T) {
+	a := assert.New(t, false)
+	l := NewLocale("lang", "zh-CN", "en")
+
+	var notFound http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		l.Redirect(w, r, http.StatusFound)
+	})
+	g := NewGroup(call, notFound, methodNotAllowedBuilder, optionsHandlerBuilder)
+	g.New("locale", l).Get("/posts", rest.BuildHandler(a, 201, "", nil))
+
+	rest.Get(a, "/en/posts").Do(g).Status(201)
+
+	r := rest.Get(a, "/posts?page=2").Header(header.AcceptLanguage, "en-US").Request()
+	w := httptest.NewRecorder()
+	g.ServeHTTP(w, r)
+	a.Equal(w.Code, http.StatusFound).
+		Equal(w.Header().Get(header.Location), "/en/posts?page=2").
+		Equal(w.Header().Get(header.Vary), header.AcceptLanguage)
+}
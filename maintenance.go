@@ -0,0 +1,200 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+package mux
+
+import (
+	"net/http"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/issue9/mux/v9/header"
+	"github.com/issue9/mux/v9/types"
+)
+
+type (
+	// Maintenance 维护模式的设置
+	//
+	// 处于维护模式的路由对所有请求返回 503，
+	// 只有匹配 Patterns 的路由项或是来自 CIDRs 的客户端可以正常访问。
+	Maintenance struct {
+		// RetryAfter 输出到 Retry-After 报头的值
+		//
+		// 以秒为单位输出，为零值表示不输出该报头。
+		RetryAfter time.Duration
+
+		// Body 503 时输出的内容
+		//
+		// 为空表示输出 [http.StatusText] 的内容。
+		Body []byte
+
+		// ContentType Body 的类型
+		//
+		// 为空表示 text/plain; charset=utf-8，仅在 Body 不为空时有效。
+		ContentType string
+
+		// Patterns 允许访问的路由项前缀
+		//
+		// 与添加路由项时的 pattern 进行比较，比如 /health 表示所有以 /health 开头的路由项都可以访问。
+		Patterns []string
+
+		// CIDRs 允许访问的客户端地址
+		//
+		// 格式为 CIDR，比如 192.168.1.0/24，也可以是单个的 IP 地址。
+		CIDRs []string
+
+		// Trusted 可信任的代理地址
+		//
+		// 只有来自这些地址的请求，才会从 Forwarded 或是 X-Forwarded-For 报头中查找客户端的地址，
+		// 格式与 CIDRs 相同。
+		Trusted []string
+	}
+
+	// 由 [Maintenance] 解析之后的内容
+	maintenance struct {
+		retryAfter  string
+		body        []byte
+		contentType string
+		patterns    []string
+		cidrs       []netip.Prefix
+		trusted     []netip.Prefix
+	}
+)
+
+// 由 [Router.Maintenance] 明确退出维护模式时保存的值，用于与未设置的状态进行区分。
+var noMaintenance = &maintenance{}
+
+func (m *Maintenance) build() (*maintenance, error) {
+	cidrs, err := tryParsePrefixes("Maintenance.CIDRs", m.CIDRs)
+	if err != nil {
+		return nil, err
+	}
+	trusted, err := tryParsePrefixes("Maintenance.Trusted", m.Trusted)
+	if err != nil {
+		return nil, err
+	}
+
+	mm := &maintenance{
+		body:        slices.Clone(m.Body),
+		contentType: m.ContentType,
+		patterns:    slices.Clone(m.Patterns),
+		cidrs:       cidrs,
+		trusted:     trusted,
+	}
+
+	if m.RetryAfter > 0 { // 向上取整，避免不足一秒的值输出为 0。
+		mm.retryAfter = strconv.FormatInt(int64((m.RetryAfter+time.Second-1)/time.Second), 10)
+	}
+	if mm.contentType == "" {
+		mm.contentType = "text/plain; charset=utf-8"
+	}
+
+	return mm, nil
+}
+
+// Maintenance 设置维护模式
+//
+// m 为 nil 表示退出维护模式。可以在运行时随时切换，不会影响正在处理的请求。
+// 调用之后，当前路由将不再受 [Group.Maintenance] 的影响。
+// 当 m 中的 CIDRs 或是 Trusted 格式错误时，会触发 panic。
+func (r *Router[T]) Maintenance(m *Maintenance) {
+	if err := r.TryMaintenance(m); err != nil {
+		panic(err)
+	}
+}
+
+// TryMaintenance 设置维护模式
+//
+// 与 [Router.Maintenance] 相同，但是在出错时返回错误信息，而不是 panic。
+func (r *Router[T]) TryMaintenance(m *Maintenance) error {
+	if m == nil {
+		r.maintenance.Store(noMaintenance)
+		return nil
+	}
+
+	mm, err := m.build()
+	if err != nil {
+		return err
+	}
+	r.maintenance.Store(mm)
+	return nil
+}
+
+// InMaintenance 是否处于维护模式
+func (r *Router[T]) InMaintenance() bool { return r.currentMaintenance() != nil }
+
+// 当前生效的维护模式设置
+//
+// 路由自身的设置优先于 [Group.Maintenance]，返回 nil 表示未处于维护模式。
+func (r *Router[T]) currentMaintenance() *maintenance {
+	m := r.maintenance.Load()
+	if m == nil && r.groupMaintenance != nil {
+		m = r.groupMaintenance.Load()
+	}
+
+	if m == noMaintenance {
+		return nil
+	}
+	return m
+}
+
+// Maintenance 设置所有路由的维护模式
+//
+// 包括之后通过 [Group.Add] 等方法添加的路由，m 为 nil 表示退出维护模式。
+// 已经通过 [Router.Maintenance] 单独设置过的路由，不受此方法的影响。
+// 当 m 中的 CIDRs 或是 Trusted 格式错误时，会触发 panic。
+func (g *Group[T]) Maintenance(m *Maintenance) {
+	if err := g.TryMaintenance(m); err != nil {
+		panic(err)
+	}
+}
+
+// TryMaintenance 设置所有路由的维护模式
+//
+// 与 [Group.Maintenance] 相同，但是在出错时返回错误信息，而不是 panic。
+func (g *Group[T]) TryMaintenance(m *Maintenance) error {
+	if m == nil {
+		g.maintenance.Store(nil)
+		return nil
+	}
+
+	mm, err := m.build()
+	if err != nil {
+		return err
+	}
+	g.maintenance.Store(mm)
+	return nil
+}
+
+// 是否允许访问
+//
+// node 为匹配的节点，可能为空。
+func (m *maintenance) allow(req *http.Request, node types.Node) bool {
+	if node != nil {
+		pattern := node.Pattern()
+		if slices.ContainsFunc(m.patterns, func(p string) bool { return strings.HasPrefix(pattern, p) }) {
+			return true
+		}
+	}
+
+	return len(m.cidrs) > 0 && containsAddr(m.cidrs, clientAddr(req, m.trusted))
+}
+
+func (m *maintenance) handle(w http.ResponseWriter) {
+	if m.retryAfter != "" {
+		w.Header().Set(header.RetryAfter, m.retryAfter)
+	}
+
+	if len(m.body) == 0 {
+		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set(header.ContentType, m.contentType)
+	w.WriteHeader(http.StatusServiceUnavailable)
+	w.Write(m.body)
+}
@@ -0,0 +1,131 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+package mux
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/issue9/assert/v4"
+	"github.com/issue9/assert/v4/rest"
+
+	"github.com/issue9/mux/v9/header"
+	"github.com/issue9/mux/v9/types"
+)
+
+func TestRouter_Maintenance(t *testing.T) {
+	a := assert.New(t, false)
+
+	r := newRouter(a, "def")
+	r.Get("/users", rest.BuildHandler(a, 201, "", nil)).
+		Get("/health/live", rest.BuildHandler(a, 202, "", nil))
+	a.False(r.InMaintenance())
+
+	r.Maintenance(&Maintenance{
+		RetryAfter:  2 * time.Minute,
+		Body:        []byte(`{"status":"maintenance"}`),
+		ContentType: "application/json",
+		Patterns:    []string{"/health"},
+		CIDRs:       []string{"10.0.0.0/8"},
+		Trusted:     []string{"192.168.1.1"},
+	})
+	a.True(r.InMaintenance())
+
+	rest.Get(a, "/users").Do(r).
+		Status(http.StatusServiceUnavailable).
+		Header(header.RetryAfter, "120").
+		Header(header.ContentType, "application/json").
+		StringBody(`{"status":"maintenance"}`)
+	rest.Get(a, "/not-exists").Do(r).Status(http.StatusServiceUnavailable)
+	rest.Get(a, "/health/live").Do(r).Status(202) // Patterns
+
+	// CIDRs
+	serve := func(remote, forwarded string) int {
+		req := rest.Get(a, "/users").Request()
+		req.RemoteAddr = remote
+		if forwarded != "" {
+			req.Header.Set(header.XForwardedFor, forwarded)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+	a.Equal(serve("10.1.1.1:8080", ""), 201).
+		Equal(serve("192.168.1.1:8080", "10.1.1.1"), 201).
+		Equal(serve("192.168.1.2:8080", "10.1.1.1"), http.StatusServiceUnavailable) // 不可信任的代理
+
+	// 默认内容
+	r.Maintenance(&Maintenance{})
+	rest.Get(a, "/users").Do(r).
+		Status(http.StatusServiceUnavailable).
+		Header(header.RetryAfter, "").
+		StringBody(http.StatusText(http.StatusServiceUnavailable) + "\n")
+
+	r.Maintenance(nil)
+	a.False(r.InMaintenance())
+	rest.Get(a, "/users").Do(r).Status(201)
+
+	// 不足一秒向上取整
+	r.Maintenance(&Maintenance{RetryAfter: 500 * time.Millisecond})
+	rest.Get(a, "/users").Do(r).Status(http.StatusServiceUnavailable).Header(header.RetryAfter, "1")
+	r.Maintenance(&Maintenance{RetryAfter: 1500 * time.Millisecond})
+	rest.Get(a, "/users").Do(r).Status(http.StatusServiceUnavailable).Header(header.RetryAfter, "2")
+	r.Maintenance(nil)
+
+	err := r.TryMaintenance(&Maintenance{CIDRs: []string{"10.0.0.0/xx"}})
+	var ive *types.InvalidValueError
+	a.True(errors.As(err, &ive)).
+		Equal(ive.Name, "Maintenance.CIDRs").
+		Equal(ive.Value, "10.0.0.0/xx").
+		False(r.InMaintenance())
+
+	err = r.TryMaintenance(&Maintenance{Trusted: []string{"192.168.1"}})
+	a.True(errors.As(err, &ive)).Equal(ive.Name, "Maintenance.Trusted")
+
+	a.Panic(func() {
+		r.Maintenance(&Maintenance{CIDRs: []string{"10.0.0.0/xx"}})
+	})
+}
+
+func TestGroup_Maintenance(t *testing.T) {
+	a := assert.New(t, false)
+
+	g := newGroup(a)
+	r1 := g.New("r1", NewPathVersion("", "v1"))
+	r1.Get("/users", rest.BuildHandler(a, 201, "", nil))
+
+	g.Maintenance(&Maintenance{})
+	r2 := g.New("r2", nil) // 之后添加的路由
+	r2.Get("/users", rest.BuildHandler(a, 202, "", nil))
+	a.True(r1.InMaintenance()).True(r2.InMaintenance())
+	rest.Get(a, "/v1/users").Do(g).Status(http.StatusServiceUnavailable)
+	rest.Get(a, "/users").Do(g).Status(http.StatusServiceUnavailable)
+
+	// 单独设置
+	r1.Maintenance(nil)
+	rest.Get(a, "/v1/users").Do(g).Status(201)
+	rest.Get(a, "/users").Do(g).Status(http.StatusServiceUnavailable)
+
+	g.Maintenance(nil)
+	a.False(r1.InMaintenance()).False(r2.InMaintenance())
+	rest.Get(a, "/users").Do(g).Status(202)
+
+	// 单独设置的路由不受 Group.Maintenance 的影响
+	g.Maintenance(&Maintenance{})
+	a.False(r1.InMaintenance()).True(r2.InMaintenance())
+	rest.Get(a, "/v1/users").Do(g).Status(201)
+	r1.Maintenance(&Maintenance{RetryAfter: time.Second})
+	g.Maintenance(nil)
+	a.True(r1.InMaintenance()).False(r2.InMaintenance())
+	rest.Get(a, "/v1/users").Do(g).Status(http.StatusServiceUnavailable).Header(header.RetryAfter, "1")
+
+	a.NotError(g.TryMaintenance(nil))
+	a.Error(g.TryMaintenance(&Maintenance{CIDRs: []string{"x"}}))
+	a.False(r2.InMaintenance())
+	a.Panic(func() { g.Maintenance(&Maintenance{Trusted: []string{"x"}}) })
+}
@@ -5,12 +5,20 @@
 package mux
 
 import (
+	"errors"
+	"fmt"
 	"log"
 	"mime"
 	"net/http"
+	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/issue9/errwrap"
 
 	"github.com/issue9/mux/v9/header"
+	"github.com/issue9/mux/v9/internal/idna"
 	"github.com/issue9/mux/v9/internal/syntax"
 	"github.com/issue9/mux/v9/internal/tree"
 	"github.com/issue9/mux/v9/types"
@@ -30,10 +38,36 @@ type (
 
 	MatcherFunc func(*http.Request, *types.Context) bool
 
+	// URLBuilder 生成与 [Matcher] 相关的地址片段
+	//
+	// 如果 [Router] 在 [Group] 中关联的 [Matcher] 实现了此接口，
+	// 那么 [Router.URL] 会在 [WithURLDomain] 指定的内容与路径之间插入由 BuildURL 生成的内容。
+	// 比如 [NewPathVersion] 会插入版本号：
+	//
+	//	https://example.com/v1/path
+	URLBuilder interface {
+		// BuildURL 根据 params 生成地址片段
+		//
+		// params 为传递给 [Router.URL] 的参数。
+		BuildURL(params map[string]string) (string, error)
+	}
+
+	andMatcher []Matcher
+
+	orMatcher []Matcher
+
 	// Hosts 限定域名的匹配工具
+	//
+	// 没有实现 [URLBuilder]，[Router.URL] 生成的地址不会包含域名，
+	// 如果需要带域名的地址，可以通过 [Hosts.URL] 生成域名部分之后再与 [Router.URL] 的返回值拼接。
 	Hosts struct {
-		i    *syntax.Interceptors
-		tree *tree.Tree[any]
+		i     *syntax.Interceptors
+		tree  *tree.Tree[any] // 不带端口的域名
+		ports *tree.Tree[any] // 带端口的域名，仅用于匹配带端口的请求。
+
+		locker  sync.RWMutex
+		domains []string // 按添加顺序保存的域名，用于生成地址。
+		scheme  string
 	}
 
 	pathVersion struct {
@@ -41,42 +75,111 @@ type (
 		versions  []string // 匹配的版本号列表，需要以 / 作分隔，比如 /v3/  /v4/  /v11/
 	}
 
-	headerVersion struct {
+	// HeaderVersion 匹配报头 Accept 中版本号的 [Matcher] 实现
+	HeaderVersion struct {
 		paramName string
 		acceptKey string
 		versions  []string
+		parsed    []semver // 与 versions 一一对应，无法解析的为 nil。
+		def       atomic.Pointer[string]
 		errlog    func(error)
 	}
 )
 
 func (f MatcherFunc) Match(r *http.Request, p *types.Context) bool { return f(r, p) }
 
-func anyRouter(*http.Request, *types.Context) bool { return true }
+// 匹配所有请求
+type anyMatcher struct{}
+
+func (anyMatcher) Match(*http.Request, *types.Context) bool { return true }
+
+func (anyMatcher) String() string { return "*" }
+
+// 返回 m 的描述信息
+//
+// 如果 m 实现了 [fmt.Stringer]，则采用其返回值，否则返回类型名称。
+func describeMatcher(m Matcher) string {
+	if m == nil {
+		return anyMatcher{}.String()
+	}
+	if s, ok := m.(fmt.Stringer); ok {
+		return s.String()
+	}
+	return fmt.Sprintf("%T", m)
+}
 
 // AndMatcher 按顺序符合每一个要求
 //
 // 前一个对象返回的实例将作为下一个对象的输入参数。
-func AndMatcher(m ...Matcher) Matcher {
-	return MatcherFunc(func(r *http.Request, ctx *types.Context) bool {
-		for _, mm := range m {
-			if !mm.Match(r, ctx) {
-				return false
+// 生成地址时，会依次调用每个实现了 [URLBuilder] 的元素。
+func AndMatcher(m ...Matcher) Matcher { return andMatcher(m) }
+
+func (m andMatcher) Match(r *http.Request, ctx *types.Context) bool {
+	for _, mm := range m {
+		if !mm.Match(r, ctx) {
+			return false
+		}
+	}
+	return true
+}
+
+// String 以 AND 连接各个元素的描述信息
+func (m andMatcher) String() string {
+	ss := make([]string, 0, len(m))
+	for _, mm := range m {
+		s := describeMatcher(mm)
+		if o, ok := mm.(orMatcher); ok && len(o) > 1 {
+			s = "(" + s + ")"
+		}
+		ss = append(ss, s)
+	}
+	return strings.Join(ss, " AND ")
+}
+
+func (m andMatcher) BuildURL(params map[string]string) (string, error) {
+	var buf strings.Builder
+	for _, mm := range m {
+		if b, ok := mm.(URLBuilder); ok {
+			s, err := b.BuildURL(params)
+			if err != nil {
+				return "", err
 			}
+			buf.WriteString(s)
 		}
-		return true
-	})
+	}
+	return buf.String(), nil
 }
 
 // OrMatcher 仅需符合一个要求
-func OrMatcher(m ...Matcher) Matcher {
-	return MatcherFunc(func(r *http.Request, ctx *types.Context) bool {
-		for _, mm := range m {
-			if ok := mm.Match(r, ctx); ok {
-				return true
-			}
+//
+// 生成地址时，采用第一个实现了 [URLBuilder] 的元素。
+func OrMatcher(m ...Matcher) Matcher { return orMatcher(m) }
+
+func (m orMatcher) Match(r *http.Request, ctx *types.Context) bool {
+	for _, mm := range m {
+		if ok := mm.Match(r, ctx); ok {
+			return true
 		}
-		return false
-	})
+	}
+	return false
+}
+
+// String 以 OR 连接各个元素的描述信息
+func (m orMatcher) String() string {
+	ss := make([]string, 0, len(m))
+	for _, mm := range m {
+		ss = append(ss, describeMatcher(mm))
+	}
+	return strings.Join(ss, " OR ")
+}
+
+func (m orMatcher) BuildURL(params map[string]string) (string, error) {
+	for _, mm := range m {
+		if b, ok := mm.(URLBuilder); ok {
+			return b.BuildURL(params)
+		}
+	}
+	return "", nil
 }
 
 // AndMatcherFunc 需同时符合每一个要求
@@ -101,8 +204,12 @@ func f2i(f ...func(*http.Request, *types.Context) bool) []Matcher {
 func NewHosts(lock bool, domain ...string) *Hosts {
 	i := syntax.NewInterceptors()
 	f := func(types.Node) any { return nil }
-	t := tree.New("host", lock, i, nil, false, f, f)
-	h := &Hosts{tree: t, i: i}
+	h := &Hosts{
+		tree:   tree.New("host", lock, i, nil, false, f, f),
+		ports:  tree.New("host", lock, i, nil, false, f, f),
+		i:      i,
+		scheme: "https",
+	}
 	h.Add(domain...)
 	return h
 }
@@ -112,20 +219,50 @@ func NewHosts(lock bool, domain ...string) *Hosts {
 // NOTE: 拦截器只有在注册之后添加的域名才有效果。
 func (hs *Hosts) RegisterInterceptor(f InterceptorFunc, name ...string) { hs.i.Add(f, name...) }
 
+// Match 判断请求的域名是否符合要求
+//
+// 如果请求中带了端口，会先与带端口的域名进行匹配，不匹配时再以不带端口的域名与其它域名进行匹配，
+// 所以不带端口的域名中的参数不会包含端口部分。
+// 国际化域名会被转换为 punycode 之后再进行匹配。
+//
+// NOTE: 转换仅包含小写化和 punycode 编码，不包含 UTS #46 中的字符映射和 NFC 规范化，
+// 所以同一域名的不同 Unicode 表示形式，比如全角字符或是组合字符，可能无法匹配。
 func (hs *Hosts) Match(r *http.Request, ctx *types.Context) bool {
-	h := r.Host // r.URL.Hostname() 可能为空，r.Host 一直有值！
-	if i := strings.LastIndexByte(h, ':'); i != -1 && validOptionalPort(h[i:]) {
-		h = h[:i]
-	}
-	if strings.HasPrefix(h, "[") && strings.HasSuffix(h, "]") { // ipv6
-		h = h[1 : len(h)-1]
+	h, port := requestHost(r)
+
+	p := ctx.Path
+	defer func() { ctx.Path = p }()
+
+	if len(port) > 1 {
+		ctx.Path = h + port
+		if _, _, exists := hs.ports.Handler(ctx, http.MethodGet); exists {
+			return true
+		}
 	}
 
-	ctx.Path = strings.ToLower(h)
+	ctx.Path = h
 	_, _, exists := hs.tree.Handler(ctx, http.MethodGet)
 	return exists
 }
 
+// 获取请求的域名和端口
+//
+// 域名会被转换为小写的 punycode 格式，端口带 : 前缀，不存在时为空。
+func requestHost(r *http.Request) (host, port string) {
+	host = r.Host // r.URL.Hostname() 可能为空，r.Host 一直有值！
+	if i := strings.LastIndexByte(host, ':'); i != -1 && validOptionalPort(host[i:]) {
+		host, port = host[:i], host[i:]
+	}
+	if strings.HasPrefix(host, "[") && strings.HasSuffix(host, "]") { // ipv6
+		host = host[1 : len(host)-1]
+	}
+
+	if a, err := idna.ToASCII(host); err == nil {
+		return a, port
+	}
+	return strings.ToLower(host), port
+}
+
 // 源自 https://github.com/golang/go/blob/d8762b2f4532cc2e5ec539670b88bbc469a13938/src/net/url/url.go#L769
 func validOptionalPort(port string) bool {
 	if port == "" {
@@ -148,19 +285,133 @@ func validOptionalPort(port string) bool {
 //
 //	api.example.com
 //	{sub:[a-z]+}.example.com
+//	api.example.com:8080
+//
+// 带端口的域名仅匹配该端口的请求，不带端口的域名匹配任意端口的请求。
+// 国际化域名会被转换为 punycode 格式。
 //
 // 如果存在命名参数，也可以通过也可通过 [types.Params] 接口获取。
 // 当语法错误时，会触发 panic，可通过 [CheckSyntax] 检测语法的正确性。
 func (hs *Hosts) Add(domain ...string) {
+	if err := hs.TryAdd(domain...); err != nil {
+		panic(err)
+	}
+}
+
+// TryAdd 添加新的域名
+//
+// 与 [Hosts.Add] 相同，但是在出错时返回错误信息，而不是 panic。
+// 会尝试添加所有的域名，返回的错误为所有出错域名的错误信息的合集。
+func (hs *Hosts) TryAdd(domain ...string) error {
+	hs.locker.Lock()
+	defer hs.locker.Unlock()
+
+	errs := make([]error, 0, len(domain))
 	for _, d := range domain {
-		err := hs.tree.Add(strings.ToLower(d), hs.emptyHandlerFunc, nil, http.MethodGet)
-		if err != nil {
-			panic(err)
+		d = hostPattern(d)
+		if err := hs.treeOf(d).Add(d, hs.emptyHandlerFunc, nil, http.MethodGet); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", d, err))
+			continue
 		}
+		hs.domains = append(hs.domains, d)
+	}
+	return errors.Join(errs...)
+}
+
+func (hs *Hosts) Delete(domain string) {
+	hs.locker.Lock()
+	defer hs.locker.Unlock()
+
+	domain = hostPattern(domain)
+	hs.treeOf(domain).Remove(domain)
+	hs.domains = slices.DeleteFunc(hs.domains, func(d string) bool { return d == domain })
+}
+
+// 返回保存域名 d 的树
+func (hs *Hosts) treeOf(d string) *tree.Tree[any] {
+	if hostHasPort(d) {
+		return hs.ports
+	}
+	return hs.tree
+}
+
+// 域名 d 是否带有端口
+//
+// 仅查找最后一个语法部分之后的内容，IPv6 地址只有在以 [] 包含时才能带端口。
+func hostHasPort(d string) bool {
+	d = d[strings.LastIndexByte(d, '}')+1:]
+	i := strings.LastIndexByte(d, ':')
+	if i < 0 || i == len(d)-1 || !validOptionalPort(d[i:]) {
+		return false
+	}
+	return !strings.Contains(d[:i], ":") || strings.HasSuffix(d[:i], "]")
+}
+
+// String 返回域名列表的描述信息，比如 host=example.com,{sub}.example.com
+func (hs *Hosts) String() string { return "host=" + strings.Join(hs.Domains(), ",") }
+
+// Domains 返回所有的域名
+//
+// 返回的是转换为 punycode 之后的值，按添加顺序排列。
+func (hs *Hosts) Domains() []string {
+	hs.locker.RLock()
+	defer hs.locker.RUnlock()
+	return slices.Clone(hs.domains)
+}
+
+// SetURLScheme 指定 [Hosts.URL] 生成地址时的协议
+//
+// 默认值为 https，如果为空，则生成以 // 开头的地址。
+func (hs *Hosts) SetURLScheme(scheme string) {
+	hs.locker.Lock()
+	defer hs.locker.Unlock()
+	hs.scheme = scheme
+}
+
+// URL 根据参数生成带协议的域名
+//
+// 采用第一个添加的域名作为模板，比如 {tenant}.example.com，
+// 在 params 为 {"tenant": "abc"} 时会生成 https://abc.example.com。
+//
+// [Router.URL] 不会自动带上此域名，如果需要生成跨子域名的完整地址，可以自行拼接：
+//
+//	host, err := hosts.URL(params)
+//	path, err := router.URL(false, "/users/{id}", params)
+//	u := host + path
+//
+// 此时 router 不应该指定 [WithURLDomain]。
+func (hs *Hosts) URL(params map[string]string) (string, error) {
+	hs.locker.RLock()
+	defer hs.locker.RUnlock()
+
+	if len(hs.domains) == 0 {
+		return "", nil
+	}
+
+	buf := errwrap.StringBuilder{}
+	if hs.scheme != "" {
+		buf.WString(hs.scheme).WByte(':')
+	}
+	buf.WString("//")
+	if err := hs.treeOf(hs.domains[0]).URL(&buf, hs.domains[0], params); err != nil {
+		return "", err
 	}
+	return buf.String(), buf.Err
 }
 
-func (hs *Hosts) Delete(domain string) { hs.tree.Remove(domain) }
+// 将域名转换为小写的 punycode 格式，包含语法部分的标签保持不变。
+func hostPattern(domain string) string {
+	labels := strings.Split(strings.ToLower(domain), ".")
+	for i, l := range labels {
+		if strings.ContainsAny(l, "{}") {
+			continue
+		}
+		if a, err := idna.ToASCII(l); err == nil {
+			labels[i] = a
+		}
+	}
+	return strings.Join(labels, ".")
+}
 
 func (hs *Hosts) emptyHandlerFunc() {}
 
@@ -169,13 +420,16 @@ func (hs *Hosts) emptyHandlerFunc() {}
 // param 将版本号作为参数保存到上下文中是的名称，如果不需要保存参数，可以设置为空值；
 // version 版本的值，可以为空，表示匹配任意值；
 //
-// NOTE: 会修改 [http.Request.URL.Path] 的值，去掉匹配的版本号路径部分，比如：
+// 匹配成功之后，会将 [types.Context.Path] 中匹配的版本号路径部分去掉，但不会修改 [http.Request] 的内容，比如：
 //
 //	/v1/path.html
 //
-// 如果匹配 v1 版本，会修改为：
+// 如果匹配 v1 版本，[types.Context.Path] 会修改为：
 //
 //	/path.html
+//
+// 返回的对象实现了 [URLBuilder]，[Router.URL] 生成的地址会带上版本号，
+// 如果 [Router.URL] 的参数中包含名为 param 的值，则采用该值作为版本号，否则采用 version 中的第一个值。
 func NewPathVersion(param string, version ...string) Matcher {
 	for i, v := range version {
 		if v == "" {
@@ -200,6 +454,18 @@ func NewPathVersion(param string, version ...string) Matcher {
 // errlog 错误日志输出通道，如果为空则采用 [log.Default]；
 // key 表示在 accept 报头中的表示版本号的参数名，如果为空则采用 version；
 // version 版本的值，可能为空，表示匹配任意值；
+//
+// 客户端提交的版本号可以是以下格式，多个条件之间以空格或是逗号分隔：
+//   - 2、2.3 与 version 中的值完全相同；
+//   - 2.x、2.3.* 以 2 或 2.3 开头，比如 2.x 可以匹配 version 中的 2 或是 2.3.1，但 2.3.x 不能匹配 2；
+//   - ^2.3 主版本号相同且不小于 2.3；
+//   - ~2.3 主版本号和次版本号相同且不小于 2.3；
+//   - >=2、>2、<=2、<2、=2 比较运算；
+//
+// 与 version 中的值完全相同时优先采用该值，否则在多个版本都符合要求时，选择其中最高的版本。
+// 无法解析为数值的版本号，只能与客户端提交的版本号完全相同时才能匹配。
+//
+// 返回的对象为 *[HeaderVersion]，可以通过类型断言调用 [HeaderVersion.SetDefault] 等方法。
 func NewHeaderVersion(param, key string, errlog func(error), version ...string) Matcher {
 	if key == "" {
 		key = "version"
@@ -209,45 +475,121 @@ func NewHeaderVersion(param, key string, errlog func(error), version ...string)
 		errlog = func(err error) { log.Println(err) }
 	}
 
-	return &headerVersion{
+	v := &HeaderVersion{
 		paramName: param,
 		acceptKey: key,
 		versions:  version,
+		parsed:    make([]semver, len(version)),
 		errlog:    errlog,
 	}
+	for i, ver := range version {
+		if p, wildcard, ok := parseVersion(ver); ok && !wildcard {
+			v.parsed[i] = p
+		}
+	}
+	return v
+}
+
+// SetDefault 指定客户端未提交版本号时采用的值
+//
+// def 的格式与客户端提交的版本号相同，可以是一个范围，比如 ^2，
+// 为空表示客户端未提交版本号时不匹配。
+func (v *HeaderVersion) SetDefault(def string) { v.def.Store(&def) }
+
+func (v *HeaderVersion) defaultVersion() string {
+	if def := v.def.Load(); def != nil {
+		return *def
+	}
+	return ""
+}
+
+// Versions 支持的版本号列表
+func (v *HeaderVersion) Versions() []string { return slices.Clone(v.versions) }
+
+// NotAcceptable 向客户端输出 406 以及支持的版本号列表
+//
+// 可以在 [Group] 的 404 处理中调用此方法，告之客户端当前支持的版本号，
+// 也可以通过 [WithNotAcceptable] 由 [Group] 在找不到匹配的 [Router] 时自动调用。
+func (v *HeaderVersion) NotAcceptable(w http.ResponseWriter) {
+	w.Header().Set(header.ContentType, header.Plain+"; charset="+header.UTF8)
+	w.WriteHeader(http.StatusNotAcceptable)
+	w.Write([]byte(v.acceptKey + ": " + strings.Join(v.versions, ", ")))
+}
+
+// String 返回版本号列表的描述信息，比如 header-version=1,2;default=2
+func (v *HeaderVersion) String() string {
+	s := "header-version=" + strings.Join(v.versions, ",")
+	if def := v.defaultVersion(); def != "" {
+		s += ";default=" + def
+	}
+	return s
 }
 
-func (v *headerVersion) Match(r *http.Request, ctx *types.Context) bool {
-	header := r.Header.Get(header.Accept)
-	if header == "" {
+func (v *HeaderVersion) Match(r *http.Request, ctx *types.Context) bool {
+	ver := v.defaultVersion()
+	if h := r.Header.Get(header.Accept); h != "" {
+		_, ps, err := mime.ParseMediaType(h)
+		if err != nil {
+			v.errlog(err)
+			return false
+		}
+		if val := ps[v.acceptKey]; val != "" {
+			ver = val
+		}
+	}
+
+	if ver == "" {
 		return false
 	}
 
-	_, ps, err := mime.ParseMediaType(header)
-	if err != nil {
-		v.errlog(err)
+	vv, found := v.match(ver)
+	if !found {
 		return false
 	}
 
-	ver := ps[v.acceptKey]
-	for _, vv := range v.versions {
-		if vv == ver {
-			if v.paramName != "" {
-				ctx.Set(v.paramName, vv)
-			}
-			return true
+	if v.paramName != "" {
+		ctx.Set(v.paramName, vv)
+	}
+	return true
+}
+
+// 从 v.versions 中查找与 ver 匹配的版本
+//
+// 完全相同的优先，否则返回符合要求的最高版本。
+func (v *HeaderVersion) match(ver string) (string, bool) {
+	if slices.Contains(v.versions, ver) {
+		return ver, true
+	}
+
+	vr, ok := parseVersionRange(ver)
+	if !ok { // 无法解析的版本号，只能完全匹配。
+		return "", false
+	}
+
+	index := -1
+	for i, p := range v.parsed {
+		if p == nil || !vr.match(v.versions[i], p) {
+			continue
+		}
+
+		if index < 0 || p.compare(v.parsed[index]) > 0 {
+			index = i
 		}
 	}
-	return false
+
+	if index < 0 {
+		return "", false
+	}
+	return v.versions[index], true
 }
 
-func (v *pathVersion) Match(r *http.Request, ctx *types.Context) bool {
-	p := r.URL.Path
+func (v *pathVersion) Match(_ *http.Request, ctx *types.Context) bool {
+	p := ctx.Path
 	for _, ver := range v.versions {
 		if strings.HasPrefix(p, ver) {
 			vv := ver[:len(ver)-1]
 
-			r.URL.Path = strings.TrimPrefix(p, vv)
+			ctx.Path = strings.TrimPrefix(p, vv)
 			if v.paramName != "" {
 				ctx.Set(v.paramName, vv)
 			}
@@ -257,3 +599,28 @@ func (v *pathVersion) Match(r *http.Request, ctx *types.Context) bool {
 	}
 	return false
 }
+
+func (v *pathVersion) String() string {
+	vs := make([]string, 0, len(v.versions))
+	for _, ver := range v.versions {
+		vs = append(vs, strings.Trim(ver, "/"))
+	}
+	return "path-version=" + strings.Join(vs, ",")
+}
+
+func (v *pathVersion) BuildURL(params map[string]string) (string, error) {
+	if v.paramName != "" {
+		if ver, found := params[v.paramName]; found && ver != "" {
+			if ver[0] != '/' {
+				ver = "/" + ver
+			}
+			return strings.TrimSuffix(ver, "/"), nil
+		}
+	}
+
+	if len(v.versions) == 0 {
+		return "", nil
+	}
+	ver := v.versions[0]
+	return ver[:len(ver)-1], nil
+}
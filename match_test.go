@@ -5,6 +5,8 @@
 package mux
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/issue9/assert/v4"
@@ -17,8 +19,12 @@ import (
 
 var (
 	_ Matcher = &Hosts{}
-	_ Matcher = &headerVersion{}
+	_ Matcher = &HeaderVersion{}
 	_ Matcher = &pathVersion{}
+
+	_ URLBuilder = &pathVersion{}
+	_ URLBuilder = andMatcher{}
+	_ URLBuilder = orMatcher{}
 )
 
 func TestAndMatcherFunc(t *testing.T) {
@@ -30,12 +36,14 @@ func TestAndMatcherFunc(t *testing.T) {
 	m := AndMatcherFunc(p1.Match, p2.Match)
 	r := rest.Get(a, "/v1/v2/path").Request()
 	ps := types.NewContext()
+	ps.Path = r.URL.Path
 	ok := m.Match(r, ps)
-	a.True(ok).Equal(r.URL.Path, "/path")
+	a.True(ok).Equal(ps.Path, "/path").Equal(r.URL.Path, "/v1/v2/path")
 
 	m = AndMatcherFunc(p1.Match, p2.Match)
 	r = rest.Get(a, "/v2/v1/path").Request()
 	ps = types.NewContext()
+	ps.Path = r.URL.Path
 	ok = m.Match(r, ps)
 	a.False(ok)
 }
@@ -49,18 +57,21 @@ func TestOrMatcherFunc(t *testing.T) {
 	m := OrMatcherFunc(p1.Match, p2.Match)
 	r := rest.Get(a, "/v1/v2/path").Request()
 	ps := types.NewContext()
+	ps.Path = r.URL.Path
 	ok := m.Match(r, ps)
-	a.True(ok).Equal(r.URL.Path, "/v2/path")
+	a.True(ok).Equal(ps.Path, "/v2/path")
 
 	m = OrMatcherFunc(p1.Match, p2.Match)
 	r = rest.Get(a, "/v2/v1/path").Request()
 	ps = types.NewContext()
+	ps.Path = r.URL.Path
 	ok = m.Match(r, ps)
-	a.True(ok).Equal(r.URL.Path, "/v1/path")
+	a.True(ok).Equal(ps.Path, "/v1/path")
 
 	m = OrMatcherFunc(p1.Match, p2.Match)
 	r = rest.Get(a, "/v111/v2/v1/path").Request()
 	ps = types.NewContext()
+	ps.Path = r.URL.Path
 	ok = m.Match(r, ps)
 	a.False(ok)
 }
@@ -169,6 +180,14 @@ func TestHosts_Add_Delete(t *testing.T) {
 	ps = types.NewContext()
 	a.True(h.Match(r, ps))
 
+	// TryAdd
+	err := h.TryAdd("yy.example.com", "{sub}.example.com", "{a}{b}.example.com", "zz.example.com")
+	a.Error(err).
+		Contains(err.Error(), "{sub}.example.com:").
+		Contains(err.Error(), "{a}{b}.example.com:").
+		NotContains(err.Error(), "yy.example.com:")
+	a.Equal(h.Domains(), []string{"{sub}.example.com", "yy.example.com", "zz.example.com"})
+
 	// delete {sub}.example.com
 	h.Delete("{sub}.example.com")
 	r = rest.Get(a, "https://zzz.example.com/api/path").Request()
@@ -176,6 +195,93 @@ func TestHosts_Add_Delete(t *testing.T) {
 	a.False(h.Match(r, ps))
 }
 
+func TestHosts_port_idn(t *testing.T) {
+	a := assert.New(t, false)
+
+	h := NewHosts(false, "api.example.com:8080", "{sub}.example.com", "中文.com", "Bücher.example")
+	a.Equal(h.Domains(), []string{"api.example.com:8080", "{sub}.example.com", "xn--fiq228c.com", "xn--bcher-kva.example"})
+
+	match := func(host string, ok bool, sub string) {
+		a.TB().Helper()
+		r := rest.Get(a, "http://"+host+"/test").Request()
+		ctx := types.NewContext()
+		ctx.Path = "/test"
+		a.Equal(h.Match(r, ctx), ok, host).Equal(ctx.Path, "/test")
+		if sub != "" {
+			a.Equal(ctx.MustString("sub", ""), sub, host)
+		} else {
+			a.False(ctx.Exists("sub"), host)
+		}
+	}
+
+	match("api.example.com:8080", true, "")
+	match("api.example.com:8081", true, "api") // 适配 {sub}.example.com
+	match("API.example.com", true, "api")
+	match("中文.com", true, "")
+	match("中文.com:8080", true, "")
+	match("xn--fiq228c.com", true, "")
+	match("bücher.example", true, "")
+	match("英文.com", false, "")
+
+	// 不带端口的域名，参数中不应该包含端口
+	h = NewHosts(false, "{tenant}.example.{tld}", "api.example.com:8080")
+	r := rest.Get(a, "http://a.example.com:8080/test").Request()
+	ctx := types.NewContext()
+	a.True(h.Match(r, ctx)).
+		Equal(ctx.MustString("tenant", ""), "a").
+		Equal(ctx.MustString("tld", ""), "com")
+	r = rest.Get(a, "http://api.example.com:8080/test").Request()
+	ctx = types.NewContext()
+	a.True(h.Match(r, ctx)).False(ctx.Exists("tld"))
+	a.True(hostHasPort("[::1]:8080")).
+		True(hostHasPort("{sub:[a-z]+}.example.com:80")).
+		False(hostHasPort("{sub:[a-z]+}.example.com")).
+		False(hostHasPort("::1")).
+		False(hostHasPort("example.com:"))
+
+	h = NewHosts(false, "api.example.com:8080")
+	r = rest.Get(a, "http://api.example.com/test").Request()
+	a.False(h.Match(r, types.NewContext()))
+
+	h.Delete("API.example.com:8080")
+	a.Empty(h.Domains())
+}
+
+func TestHosts_URL(t *testing.T) {
+	a := assert.New(t, false)
+
+	h := NewHosts(false)
+	u, err := h.URL(nil)
+	a.NotError(err).Empty(u)
+
+	h.Add("{tenant}.example.com", "example.com")
+	u, err = h.URL(map[string]string{"tenant": "abc"})
+	a.NotError(err).Equal(u, "https://abc.example.com")
+
+	u, err = h.URL(nil)
+	a.Error(err).Empty(u)
+
+	h.SetURLScheme("")
+	u, err = h.URL(map[string]string{"tenant": "abc"})
+	a.NotError(err).Equal(u, "//abc.example.com")
+
+	h.SetURLScheme("http")
+	h.Delete("{tenant}.example.com")
+	u, err = h.URL(map[string]string{"tenant": "abc"})
+	a.NotError(err).Equal(u, "http://example.com")
+
+	// 与 Router.URL 配合
+	g := newGroup(a)
+	h = NewHosts(false, "{tenant}.example.com")
+	r := g.New("tenant", h)
+	r.Get("/posts/{id}", rest.BuildHandler(a, 201, "", nil))
+	params := map[string]string{"id": "1", "tenant": "abc"}
+	host, err := h.URL(params)
+	a.NotError(err)
+	u, err = r.URL(true, "/posts/{id}", params)
+	a.NotError(err).Equal(host+u, "https://abc.example.com/posts/1")
+}
+
 func TestHeaderVersion_Match(t *testing.T) {
 	a := assert.New(t, false)
 
@@ -271,6 +377,55 @@ func TestHeaderVersion_Match(t *testing.T) {
 	a.False(ok)
 }
 
+func TestHeaderVersion_semver(t *testing.T) {
+	a := assert.New(t, false)
+
+	h := NewHeaderVersion("version", "", nil, "1", "2", "2.1", "2.3.1", "3.0.0", "beta").(*HeaderVersion)
+	a.Equal(h.Versions(), []string{"1", "2", "2.1", "2.3.1", "3.0.0", "beta"})
+
+	match := func(accept, ver string) {
+		a.TB().Helper()
+		r := rest.Get(a, "/test").Header(header.Accept, accept).Request()
+		ps := types.NewContext()
+		if ver == "" {
+			a.False(h.Match(r, ps), accept).Zero(ps.Count())
+			return
+		}
+		a.True(h.Match(r, ps), accept).Equal(ps.MustString("version", ""), ver)
+	}
+
+	match("application/json; version=1", "1")
+	match("application/json; version=1.5", "")
+	match("application/json; version=2", "2")
+	match("application/json; version=2.3", "")
+	match("application/json; version=2.3.x", "2.3.1")
+	match("application/json; version=2.x", "2.3.1")
+	match("application/json; version=1.5.x", "")
+	match("application/json; version=v3.x", "3.0.0")
+	match(`application/json; version="^2.1"`, "2.3.1")
+	match(`application/json; version="~2.1"`, "2.1")
+	match(`application/json; version=">=1 <2.1"`, "2")
+	match(`application/json; version=">3"`, "")
+	match(`application/json; version="<=2,>1"`, "2")
+	match("application/json; version=beta", "beta")
+	match("application/json; version=alpha", "")
+	match("application/json; version=4", "")
+
+	// 默认值
+	match("application/json", "")
+	match("", "")
+	h.SetDefault("^2")
+	match("application/json", "2.3.1")
+	match("", "2.3.1")
+	match("application/json; version=1", "1")
+
+	// NotAcceptable
+	w := httptest.NewRecorder()
+	h.NotAcceptable(w)
+	a.Equal(w.Code, http.StatusNotAcceptable).
+		Equal(w.Body.String(), "version: 1, 2, 2.1, 2.3.1, 3.0.0, beta")
+}
+
 func TestPathVersion_Match(t *testing.T) {
 	a := assert.New(t, false)
 
@@ -283,33 +438,38 @@ func TestPathVersion_Match(t *testing.T) {
 	// 相同版本号
 	r := rest.Get(a, "https://caixw.io/v1/test").Request()
 	ps := types.NewContext()
+	ps.Path = r.URL.Path
 	ok := h.Match(r, ps)
 	a.True(ok)
-	a.Equal(r.URL.Path, "/test").
+	a.Equal(ps.Path, "/test").
+		Equal(r.URL.Path, "/v1/test").
 		Equal(ps.MustString("version", "not-found"), "/v1")
 
 	// 相同版本号，未指定 key
 	h = NewPathVersion("", "v3", "/v2", "/v1")
 	r = rest.Get(a, "https://caixw.io/v1/test").Request()
 	ps = types.NewContext()
+	ps.Path = r.URL.Path
 	ok = h.Match(r, ps)
 	a.True(ok)
-	a.Equal(r.URL.Path, "/test")
+	a.Equal(ps.Path, "/test")
 
 	// 空版本
 	r = rest.Get(a, "https://caixw.io/test").Request()
 	ps = types.NewContext()
+	ps.Path = r.URL.Path
 	ok = h.Match(r, ps)
 	a.False(ok)
-	a.Equal(r.URL.Path, "/test")
+	a.Equal(ps.Path, "/test")
 
 	// 不同版本
 	r = rest.Get(a, "https://caixw.io/v111/test").Request()
 	a.NotNil(r)
 	ps = types.NewContext()
+	ps.Path = r.URL.Path
 	ok = h.Match(r, ps)
 	a.False(ok)
-	a.Equal(r.URL.Path, "/v111/test")
+	a.Equal(ps.Path, "/v111/test")
 
 	// 空值，不匹配任何内容
 
@@ -318,6 +478,26 @@ func TestPathVersion_Match(t *testing.T) {
 		Header(header.Accept, "application/json; version=1.0").
 		Request()
 	ps = types.NewContext()
+	ps.Path = r.URL.Path
 	ok = h.Match(r, ps)
 	a.False(ok)
 }
+
+func TestPathVersion_BuildURL(t *testing.T) {
+	a := assert.New(t, false)
+
+	pv := NewPathVersion("version", "v3", "/v2", "/v1")
+	h := pv.(URLBuilder)
+	u, err := h.BuildURL(nil)
+	a.NotError(err).Equal(u, "/v3")
+
+	u, err = h.BuildURL(map[string]string{"version": "/v1"})
+	a.NotError(err).Equal(u, "/v1")
+
+	u, err = h.BuildURL(map[string]string{"version": "v2"})
+	a.NotError(err).Equal(u, "/v2")
+
+	m := AndMatcher(NewHosts(false, "example.com"), pv).(URLBuilder)
+	u, err = m.BuildURL(nil)
+	a.NotError(err).Equal(u, "/v3")
+}
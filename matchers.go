@@ -0,0 +1,327 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+package mux
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strings"
+
+	"github.com/issue9/mux/v9/header"
+	"github.com/issue9/mux/v9/types"
+)
+
+type (
+	methods []string
+
+	schemes struct {
+		paramName string
+		schemes   []string
+		trusted   []netip.Prefix
+	}
+
+	cidr struct {
+		paramName string
+		prefixes  []netip.Prefix
+		trusted   []netip.Prefix
+	}
+
+	query struct {
+		paramName string
+		key       string
+		values    []string
+	}
+
+	cookie struct {
+		paramName string
+		name      string
+		values    []string
+	}
+)
+
+// NewMethods 声明限定请求方法的 [Matcher] 实例
+func NewMethods(method ...string) Matcher {
+	ms := make(methods, 0, len(method))
+	for _, m := range method {
+		ms = append(ms, strings.ToUpper(m))
+	}
+	return ms
+}
+
+func (ms methods) Match(r *http.Request, _ *types.Context) bool { return slices.Contains(ms, r.Method) }
+
+func (ms methods) String() string { return "method=" + strings.Join(ms, ",") }
+
+// NewSchemes 声明限定请求协议的 [Matcher] 实例
+//
+// param 将协议名称作为参数保存到上下文中时的名称，如果不需要保存参数，可以设置为空值；
+// trusted 为可信任的代理地址，格式为 CIDR，只有来自这些地址的请求，
+// 才会采用 Forwarded 或是 X-Forwarded-Proto 报头中的协议名称；
+// scheme 允许的协议名称，比如 http 和 https，不区分大小写；
+//
+// 当 trusted 中的值格式错误时，会触发 panic。
+func NewSchemes(param string, trusted []string, scheme ...string) Matcher {
+	ss := make([]string, 0, len(scheme))
+	for _, s := range scheme {
+		ss = append(ss, strings.ToLower(s))
+	}
+	return &schemes{paramName: param, schemes: ss, trusted: parsePrefixes(trusted)}
+}
+
+// NewTLS 声明仅匹配 HTTPS 请求的 [Matcher] 实例
+//
+// trusted 可参考 [NewSchemes] 的说明。
+func NewTLS(trusted ...string) Matcher { return NewSchemes("", trusted, "https") }
+
+func (s *schemes) Match(r *http.Request, ctx *types.Context) bool {
+	scheme := requestScheme(r, s.trusted)
+	if !slices.Contains(s.schemes, scheme) {
+		return false
+	}
+
+	if s.paramName != "" {
+		ctx.Set(s.paramName, scheme)
+	}
+	return true
+}
+
+func (s *schemes) String() string { return "scheme=" + strings.Join(s.schemes, ",") }
+
+// 获取请求的协议名称
+//
+// 只有 [http.Request.RemoteAddr] 属于 trusted 时，才会从代理相关的报头中查找，
+// 且只采用最右侧的值，即由可信任的代理添加的内容，左侧的值可能由客户端伪造。
+func requestScheme(r *http.Request, trusted []netip.Prefix) string {
+	if containsAddr(trusted, remoteAddr(r)) {
+		if fs := parseForwarded(r.Header.Values(header.Forwarded)); len(fs) > 0 {
+			if p := fs[len(fs)-1]["proto"]; p != "" {
+				return strings.ToLower(p)
+			}
+		} else if vs := r.Header.Values(header.XForwardedProto); len(vs) > 0 {
+			v := vs[len(vs)-1]
+			if i := strings.LastIndexByte(v, ','); i >= 0 {
+				v = v[i+1:]
+			}
+			if p := strings.TrimSpace(v); p != "" {
+				return strings.ToLower(p)
+			}
+		}
+	}
+
+	if r.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
+
+// NewCIDR 声明限定客户端地址的 [Matcher] 实例
+//
+// param 将客户端地址作为参数保存到上下文中时的名称，如果不需要保存参数，可以设置为空值；
+// trusted 为可信任的代理地址，格式为 CIDR，只有来自这些地址的请求，
+// 才会从 Forwarded 或是 X-Forwarded-For 报头中从右往左查找第一个非代理地址作为客户端的地址；
+// prefix 允许的客户端地址，格式为 CIDR，比如 192.168.1.0/24，也可以是单个的 IP 地址；
+//
+// 当 trusted 或是 prefix 中的值格式错误时，会触发 panic。
+func NewCIDR(param string, trusted []string, prefix ...string) Matcher {
+	return &cidr{paramName: param, prefixes: parsePrefixes(prefix), trusted: parsePrefixes(trusted)}
+}
+
+func (c *cidr) Match(r *http.Request, ctx *types.Context) bool {
+	addr := clientAddr(r, c.trusted)
+	if !containsAddr(c.prefixes, addr) {
+		return false
+	}
+
+	if c.paramName != "" {
+		ctx.Set(c.paramName, addr.String())
+	}
+	return true
+}
+
+func (c *cidr) String() string {
+	ps := make([]string, 0, len(c.prefixes))
+	for _, p := range c.prefixes {
+		ps = append(ps, p.String())
+	}
+	return "cidr=" + strings.Join(ps, ",")
+}
+
+// 获取客户端的地址
+//
+// 只有 [http.Request.RemoteAddr] 属于 trusted 时，才会从代理相关的报头中查找。
+func clientAddr(r *http.Request, trusted []netip.Prefix) netip.Addr {
+	addr := remoteAddr(r)
+	if !containsAddr(trusted, addr) {
+		return addr
+	}
+
+	var chain []netip.Addr
+	if fs := parseForwarded(r.Header.Values(header.Forwarded)); len(fs) > 0 {
+		chain = make([]netip.Addr, 0, len(fs))
+		for _, f := range fs {
+			chain = append(chain, parseAddr(f["for"]))
+		}
+	} else {
+		for _, v := range r.Header.Values(header.XForwardedFor) {
+			for item := range strings.SplitSeq(v, ",") {
+				chain = append(chain, parseAddr(item))
+			}
+		}
+	}
+
+	for i := len(chain) - 1; i >= 0; i-- {
+		a := chain[i]
+		if !a.IsValid() { // 无法识别的地址，不再信任之前的内容。
+			return addr
+		}
+		addr = a
+		if !containsAddr(trusted, a) {
+			break
+		}
+	}
+	return addr
+}
+
+func remoteAddr(r *http.Request) netip.Addr { return parseAddr(r.RemoteAddr) }
+
+// 解析地址，可以带端口，失败返回零值。
+func parseAddr(s string) netip.Addr {
+	s = strings.Trim(strings.TrimSpace(s), `"`)
+	if host, _, err := net.SplitHostPort(s); err == nil {
+		s = host
+	}
+	s = strings.TrimSuffix(strings.TrimPrefix(s, "["), "]")
+
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Addr{}
+	}
+	return addr.Unmap()
+}
+
+func parsePrefixes(s []string) []netip.Prefix {
+	ps, err := tryParsePrefixes("prefix", s)
+	if err != nil {
+		panic(err)
+	}
+	return ps
+}
+
+// 将 s 解析为 [netip.Prefix]，name 为出错时返回的 [types.InvalidValueError] 中的参数名称。
+func tryParsePrefixes(name string, s []string) ([]netip.Prefix, error) {
+	ps := make([]netip.Prefix, 0, len(s))
+	for _, v := range s {
+		if !strings.ContainsRune(v, '/') {
+			addr, err := netip.ParseAddr(v)
+			if err != nil {
+				return nil, &types.InvalidValueError{Name: name, Value: v}
+			}
+			addr = addr.Unmap()
+			ps = append(ps, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+
+		p, err := netip.ParsePrefix(v)
+		if err != nil {
+			return nil, &types.InvalidValueError{Name: name, Value: v}
+		}
+		ps = append(ps, p.Masked())
+	}
+	return ps, nil
+}
+
+func containsAddr(ps []netip.Prefix, addr netip.Addr) bool {
+	if !addr.IsValid() {
+		return false
+	}
+	return slices.ContainsFunc(ps, func(p netip.Prefix) bool { return p.Contains(addr) })
+}
+
+// 解析 Forwarded 报头
+//
+// 返回的每个元素对应一个代理节点，键名为小写。
+func parseForwarded(values []string) []map[string]string {
+	var ret []map[string]string
+	for _, v := range values {
+		for elem := range strings.SplitSeq(v, ",") {
+			m := make(map[string]string, 4)
+			for pair := range strings.SplitSeq(elem, ";") {
+				key, val, found := strings.Cut(pair, "=")
+				if !found {
+					continue
+				}
+				m[strings.ToLower(strings.TrimSpace(key))] = strings.Trim(strings.TrimSpace(val), `"`)
+			}
+			if len(m) > 0 {
+				ret = append(ret, m)
+			}
+		}
+	}
+	return ret
+}
+
+// NewQuery 声明匹配查询参数的 [Matcher] 实例
+//
+// 比如通过 ?api-version=2024-01-01 选择不同的路由。
+//
+// param 将查询参数的值保存到上下文中时的名称，如果不需要保存参数，可以设置为空值；
+// key 查询参数的名称；
+// value 允许的值，如果为空，表示只要存在非空的值即可；
+func NewQuery(param, key string, value ...string) Matcher {
+	return &query{paramName: param, key: key, values: value}
+}
+
+func (q *query) Match(r *http.Request, ctx *types.Context) bool {
+	v := r.URL.Query().Get(q.key)
+	if !matchValue(v, q.values) {
+		return false
+	}
+
+	if q.paramName != "" {
+		ctx.Set(q.paramName, v)
+	}
+	return true
+}
+
+func (q *query) String() string { return "query:" + q.key + "=" + describeValues(q.values) }
+
+// NewCookie 声明匹配 Cookie 的 [Matcher] 实例
+//
+// param 将 Cookie 的值保存到上下文中时的名称，如果不需要保存参数，可以设置为空值；
+// name Cookie 的名称；
+// value 允许的值，如果为空，表示只要存在非空的值即可；
+func NewCookie(param, name string, value ...string) Matcher {
+	return &cookie{paramName: param, name: name, values: value}
+}
+
+func (c *cookie) Match(r *http.Request, ctx *types.Context) bool {
+	ck, err := r.Cookie(c.name)
+	if err != nil || !matchValue(ck.Value, c.values) {
+		return false
+	}
+
+	if c.paramName != "" {
+		ctx.Set(c.paramName, ck.Value)
+	}
+	return true
+}
+
+func (c *cookie) String() string { return "cookie:" + c.name + "=" + describeValues(c.values) }
+
+func matchValue(v string, values []string) bool {
+	if v == "" {
+		return false
+	}
+	return len(values) == 0 || slices.Contains(values, v)
+}
+
+func describeValues(values []string) string {
+	if len(values) == 0 {
+		return "*"
+	}
+	return strings.Join(values, ",")
+}
@@ -0,0 +1,184 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+package mux
+
+import (
+	"crypto/tls"
+	"net/http"
+	"testing"
+
+	"github.com/issue9/assert/v4"
+	"github.com/issue9/assert/v4/rest"
+
+	"github.com/issue9/mux/v9/header"
+	"github.com/issue9/mux/v9/types"
+)
+
+var (
+	_ Matcher = methods{}
+	_ Matcher = &schemes{}
+	_ Matcher = &cidr{}
+	_ Matcher = &query{}
+	_ Matcher = &cookie{}
+)
+
+func TestNewMethods(t *testing.T) {
+	a := assert.New(t, false)
+
+	m := NewMethods("get", http.MethodPost)
+	a.True(m.Match(rest.Get(a, "/path").Request(), types.NewContext())).
+		True(m.Match(rest.Post(a, "/path", nil).Request(), types.NewContext())).
+		False(m.Match(rest.Delete(a, "/path").Request(), types.NewContext()))
+}
+
+func TestNewSchemes(t *testing.T) {
+	a := assert.New(t, false)
+
+	m := NewSchemes("scheme", []string{"10.0.0.0/8"}, "HTTPS")
+
+	r := rest.Get(a, "/path").Request()
+	ctx := types.NewContext()
+	a.False(m.Match(r, ctx)).Zero(ctx.Count())
+
+	r.TLS = &tls.ConnectionState{}
+	a.True(m.Match(r, ctx)).Equal(ctx.MustString("scheme", ""), "https")
+
+	// 不可信的代理
+	r = rest.Get(a, "/path").Header(header.XForwardedProto, "https").Request()
+	r.RemoteAddr = "192.168.1.1:8080"
+	a.False(m.Match(r, types.NewContext()))
+
+	// 可信的代理
+	r.RemoteAddr = "10.0.0.1:8080"
+	a.True(m.Match(r, types.NewContext()))
+
+	r = rest.Get(a, "/path").Header(header.Forwarded, "for=1.1.1.1;proto=https").Request()
+	r.RemoteAddr = "10.0.0.1:8080"
+	a.True(m.Match(r, types.NewContext()))
+
+	r = rest.Get(a, "/path").Header(header.Forwarded, "for=1.1.1.1;proto=http").Header(header.XForwardedProto, "https").Request()
+	r.RemoteAddr = "10.0.0.1:8080"
+	a.False(m.Match(r, types.NewContext()))
+
+	// 只采用最右侧由代理添加的值
+	r = rest.Get(a, "/path").Header(header.Forwarded, "for=1.1.1.1;proto=https, for=2.2.2.2;proto=http").Request()
+	r.RemoteAddr = "10.0.0.1:8080"
+	a.False(m.Match(r, types.NewContext()))
+
+	r = rest.Get(a, "/path").Header(header.XForwardedProto, "https, http").Request()
+	r.RemoteAddr = "10.0.0.1:8080"
+	a.False(m.Match(r, types.NewContext()))
+
+	r = rest.Get(a, "/path").Header(header.XForwardedProto, "http").Header(header.XForwardedProto, "https").Request()
+	r.RemoteAddr = "10.0.0.1:8080"
+	a.True(m.Match(r, types.NewContext()))
+
+	// NewTLS
+	m = NewTLS()
+	r = rest.Get(a, "/path").Request()
+	a.False(m.Match(r, types.NewContext()))
+	r.TLS = &tls.ConnectionState{}
+	a.True(m.Match(r, types.NewContext()))
+
+	a.Panic(func() { NewTLS("10.0.0.0/x") })
+}
+
+func TestNewCIDR(t *testing.T) {
+	a := assert.New(t, false)
+
+	a.Panic(func() { NewCIDR("", nil, "192.168.1") })
+
+	m := NewCIDR("ip", []string{"10.0.0.0/8", "::1"}, "192.168.1.0/24", "2001:db8::/32", "1.1.1.1")
+
+	r := rest.Get(a, "/path").Request()
+	r.RemoteAddr = "192.168.1.100:1234"
+	ctx := types.NewContext()
+	a.True(m.Match(r, ctx)).Equal(ctx.MustString("ip", ""), "192.168.1.100")
+
+	r.RemoteAddr = "192.168.2.100:1234"
+	ctx = types.NewContext()
+	a.False(m.Match(r, ctx)).Zero(ctx.Count())
+
+	r.RemoteAddr = "1.1.1.1:1234"
+	a.True(m.Match(r, types.NewContext()))
+
+	// 不可信代理中的 X-Forwarded-For 被忽略
+	r = rest.Get(a, "/path").Header(header.XForwardedFor, "192.168.1.1").Request()
+	r.RemoteAddr = "8.8.8.8:1234"
+	a.False(m.Match(r, types.NewContext()))
+
+	// 可信代理
+	r = rest.Get(a, "/path").Header(header.XForwardedFor, "8.8.8.8, 192.168.1.1, 10.0.0.2").Request()
+	r.RemoteAddr = "10.0.0.1:1234"
+	ctx = types.NewContext()
+	a.True(m.Match(r, ctx)).Equal(ctx.MustString("ip", ""), "192.168.1.1")
+
+	r = rest.Get(a, "/path").Header(header.XForwardedFor, "192.168.1.1, 8.8.8.8").Request()
+	r.RemoteAddr = "10.0.0.1:1234"
+	a.False(m.Match(r, types.NewContext()))
+
+	r = rest.Get(a, "/path").Header(header.Forwarded, `for="[2001:db8::1]:4711";proto=https, for=10.0.0.3`).Request()
+	r.RemoteAddr = "[::1]:1234"
+	ctx = types.NewContext()
+	a.True(m.Match(r, ctx)).Equal(ctx.MustString("ip", ""), "2001:db8::1")
+
+	// 无法识别的地址
+	r = rest.Get(a, "/path").Header(header.Forwarded, "for=unknown").Request()
+	r.RemoteAddr = "10.0.0.1:1234"
+	a.False(m.Match(r, types.NewContext()))
+}
+
+func TestNewQuery(t *testing.T) {
+	a := assert.New(t, false)
+
+	m := NewQuery("ver", "api-version", "2024-01-01", "2025-01-01")
+	ctx := types.NewContext()
+	a.True(m.Match(rest.Get(a, "/path?api-version=2024-01-01").Request(), ctx)).
+		Equal(ctx.MustString("ver", ""), "2024-01-01")
+	a.False(m.Match(rest.Get(a, "/path?api-version=2023-01-01").Request(), types.NewContext()))
+	a.False(m.Match(rest.Get(a, "/path").Request(), types.NewContext()))
+
+	m = NewQuery("", "api-version")
+	a.True(m.Match(rest.Get(a, "/path?api-version=2023-01-01").Request(), types.NewContext()))
+	a.False(m.Match(rest.Get(a, "/path?api-version=").Request(), types.NewContext()))
+}
+
+func TestNewCookie(t *testing.T) {
+	a := assert.New(t, false)
+
+	m := NewCookie("c", "beta", "1")
+	ctx := types.NewContext()
+	a.True(m.Match(rest.Get(a, "/path").Header(header.Cookie, "beta=1").Request(), ctx)).
+		Equal(ctx.MustString("c", ""), "1")
+	a.False(m.Match(rest.Get(a, "/path").Header(header.Cookie, "beta=2").Request(), types.NewContext()))
+	a.False(m.Match(rest.Get(a, "/path").Request(), types.NewContext()))
+
+	m = NewCookie("", "beta")
+	a.True(m.Match(rest.Get(a, "/path").Header(header.Cookie, "beta=2").Request(), types.NewContext()))
+
+	// 与 AndMatcher 组合
+	m = AndMatcher(NewMethods(http.MethodGet), NewCookie("c", "beta", "1"), NewQuery("q", "v"))
+	ctx = types.NewContext()
+	a.True(m.Match(rest.Get(a, "/path?v=2").Header(header.Cookie, "beta=1").Request(), ctx)).
+		Equal(ctx.MustString("c", ""), "1").
+		Equal(ctx.MustString("q", ""), "2")
+}
+
+func TestMatchers_String(t *testing.T) {
+	a := assert.New(t, false)
+
+	a.Equal(describeMatcher(NewMethods("get", "post")), "method=GET,POST").
+		Equal(describeMatcher(NewTLS()), "scheme=https").
+		Equal(describeMatcher(NewCIDR("", nil, "10.0.0.1/8", "::1")), "cidr=10.0.0.0/8,::1/128").
+		Equal(describeMatcher(NewQuery("", "api-version", "1", "2")), "query:api-version=1,2").
+		Equal(describeMatcher(NewCookie("", "beta")), "cookie:beta=*").
+		Equal(describeMatcher(NewLocale("", "zh-CN", "en")), "locale=zh-CN,en").
+		Equal(describeMatcher(nil), "*")
+
+	h := NewHeaderVersion("", "", nil, "1", "2").(*HeaderVersion)
+	h.SetDefault("2")
+	a.Equal(describeMatcher(h), "header-version=1,2;default=2")
+	a.Equal(describeMatcher(AndMatcher(NewMethods("GET"), NewTLS())), "method=GET AND scheme=https")
+}
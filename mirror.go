@@ -0,0 +1,218 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+package mux
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"time"
+
+	"github.com/issue9/mux/v9/types"
+)
+
+// 未指定 [Mirror.MaxConcurrent] 时，同时处理的复制请求的最大数量。
+const defaultMirrorConcurrent = 100
+
+type (
+	// Mirror 将请求复制到影子路由的设置
+	//
+	// 影子路由的输出内容会被丢弃，仅将状态码和耗时通过 Report 报告给调用方，
+	// 可用于在不影响正式环境的情况下验证新的实现。
+	// 只有在原路由中匹配到路由项，且通过了维护模式、禁用和弃用等检测的请求才会被复制，
+	// 返回 404 和 405 等错误状态的请求不会被复制。
+	Mirror struct {
+		// Shadow 影子路由的名称
+		//
+		// 必须是同一 [Group] 中的路由，在请求时才查找该路由，不存在则不复制。
+		Shadow string
+
+		// Percent 复制的请求比例
+		//
+		// 取值范围为 [0, 100]，0 表示不复制，100 表示复制所有请求。
+		Percent int
+
+		// MaxBodySize 可复制的请求体的最大长度
+		//
+		// 请求体需要完整地缓存在内存中，超过此值的请求不会被复制，为 0 表示只复制没有请求体的请求。
+		MaxBodySize int64
+
+		// MaxConcurrent 同时处理的复制请求的最大数量
+		//
+		// 超过此值的复制请求会被丢弃，并通过 Report 报告，为 0 表示采用默认值 100。
+		MaxConcurrent int
+
+		// Report 报告影子路由的处理结果
+		//
+		// 在影子路由的 goroutine 中调用，丢弃的请求则在原请求的 goroutine 中调用，可以为空。
+		Report func(*MirrorResult)
+	}
+
+	// MirrorResult 影子路由的处理结果
+	MirrorResult struct {
+		Router  string        // 原路由的名称
+		Shadow  string        // 影子路由的名称
+		Request *http.Request // 复制给影子路由的请求
+		Status  int           // 影子路由返回的状态码，如果影子路由不匹配该请求，则为 404。
+		Latency time.Duration // 影子路由的耗时
+		Panic   any           // 影子路由中发生的 panic，此时 Status 为 500。
+		Dropped bool          // 因为超过 Mirror.MaxConcurrent 而被丢弃，此时 Request 为原请求，Status 和 Latency 均为零值。
+	}
+
+	// 由 [Mirror] 生成的内容
+	mirror[T any] struct {
+		Mirror
+		sem   chan struct{} // 限制同时处理的复制请求数量
+		group *Group[T]     // 用于查找影子路由
+	}
+
+	// 丢弃所有的输出内容，仅记录状态码。
+	mirrorResponse struct {
+		header http.Header
+		status int
+	}
+
+	// 在读取缓存之后，继续读取原始的请求体。
+	mirrorBody struct {
+		io.Reader
+		io.Closer
+	}
+)
+
+// Mirror 将发送给路由 name 的请求按比例复制给影子路由
+//
+// m 为 nil 表示取消复制。复制的请求在单独的 goroutine 中处理，
+// 其 context 不会因为原请求的结束而取消，但是会保留原有的值。
+func (g *Group[T]) Mirror(name string, m *Mirror) error {
+	r := g.Router(name)
+	if r == nil {
+		return &types.RouterNotFoundError{Name: name}
+	}
+
+	if m == nil {
+		r.mirror.Store(nil)
+		return nil
+	}
+
+	switch {
+	case m.Shadow == "" || m.Shadow == name: // 不能为空，也不能与原路由相同。
+		return &types.InvalidValueError{Name: "Mirror.Shadow", Value: m.Shadow}
+	case m.Percent < 0 || m.Percent > 100:
+		return &types.InvalidValueError{Name: "Mirror.Percent", Value: m.Percent}
+	case m.MaxBodySize < 0:
+		return &types.InvalidValueError{Name: "Mirror.MaxBodySize", Value: m.MaxBodySize}
+	case m.MaxConcurrent < 0:
+		return &types.InvalidValueError{Name: "Mirror.MaxConcurrent", Value: m.MaxConcurrent}
+	}
+
+	size := m.MaxConcurrent
+	if size == 0 {
+		size = defaultMirrorConcurrent
+	}
+	r.mirror.Store(&mirror[T]{Mirror: *m, sem: make(chan struct{}, size), group: g})
+	return nil
+}
+
+// 如果 r 需要复制请求，则复制给影子路由。
+//
+// 返回的是原路由应该使用的请求对象，请求体可能被替换。
+func (r *Router[T]) mirrorRequest(req *http.Request) *http.Request {
+	m := r.mirror.Load()
+	if m == nil || m.Percent <= 0 || (m.Percent < 100 && rand.IntN(100) >= m.Percent) {
+		return req
+	}
+
+	shadow := m.group.Router(m.Shadow)
+	if shadow == nil || !shadow.inflight.begin() { // 影子路由同样需要计数，由 serveMirror 结束计数。
+		return req
+	}
+
+	select {
+	case m.sem <- struct{}{}: // 由 serveMirror 释放
+	default:
+		shadow.inflight.end()
+		if m.Report != nil {
+			m.Report(&MirrorResult{Router: r.Name(), Shadow: shadow.Name(), Request: req, Dropped: true})
+		}
+		return req
+	}
+
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		buf, err := io.ReadAll(io.LimitReader(req.Body, m.MaxBodySize+1))
+		if err != nil || int64(len(buf)) > m.MaxBodySize { // 超过大小限制或是读取出错，仅恢复原请求体。
+			req.Body = &mirrorBody{Reader: io.MultiReader(bytes.NewReader(buf), req.Body), Closer: req.Body}
+			<-m.sem
+			shadow.inflight.end()
+			return req
+		}
+		body = buf
+		req.Body = &mirrorBody{Reader: bytes.NewReader(body), Closer: req.Body}
+	}
+
+	clone := req.Clone(context.WithoutCancel(req.Context()))
+	if body != nil {
+		clone.Body = io.NopCloser(bytes.NewReader(body))
+		clone.ContentLength = int64(len(body))
+	} else {
+		clone.Body = http.NoBody
+	}
+
+	go serveMirror(r.Name(), shadow, clone, m)
+
+	return req
+}
+
+func serveMirror[T any](name string, shadow *Router[T], req *http.Request, m *mirror[T]) {
+	defer shadow.inflight.end()
+	defer func() { <-m.sem }()
+
+	result := &MirrorResult{Router: name, Shadow: shadow.Name(), Request: req}
+	w := &mirrorResponse{header: http.Header{}}
+	start := time.Now()
+
+	defer func() {
+		if err := recover(); err != nil {
+			result.Panic = err
+			w.status = http.StatusInternalServerError
+		}
+
+		result.Latency = time.Since(start)
+		result.Status = w.status
+		if result.Status == 0 {
+			result.Status = http.StatusOK
+		}
+		if m.Report != nil {
+			m.Report(result)
+		}
+	}()
+
+	ctx := types.NewContext()
+	defer ctx.Destroy()
+	ctx.Path = req.URL.Path
+
+	if shadow.matcher != nil && !shadow.matcher.Match(req, ctx) {
+		w.status = http.StatusNotFound
+		return
+	}
+	shadow.serveContext(w, req, ctx)
+}
+
+func (w *mirrorResponse) Header() http.Header { return w.header }
+
+func (w *mirrorResponse) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return len(b), nil
+}
+
+func (w *mirrorResponse) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
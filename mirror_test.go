@@ -0,0 +1,238 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+package mux
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/issue9/assert/v4"
+	"github.com/issue9/assert/v4/rest"
+
+	"github.com/issue9/mux/v9/types"
+)
+
+func TestGroup_Mirror(t *testing.T) {
+	a := assert.New(t, false)
+	type key struct{}
+
+	g := newGroup(a)
+	g.New("main", NewPathVersion("", "v1")).
+		Post("/users", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, err := io.ReadAll(r.Body)
+			a.NotError(err)
+			w.WriteHeader(201)
+			_, err = w.Write(body)
+			a.NotError(err)
+		})).
+		Get("/panic", rest.BuildHandler(a, 203, "", nil)).
+		Get("/v2-only", rest.BuildHandler(a, 204, "", nil))
+
+	shadowBodies := make(chan string, 10)
+	g.New("shadow", NewPathVersion("", "v1", "v2")).
+		Post("/users", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, err := io.ReadAll(r.Body)
+			a.NotError(err).Equal(r.Context().Value(key{}), "v")
+			shadowBodies <- string(body)
+			time.Sleep(time.Millisecond)
+			w.WriteHeader(202)
+		})).
+		Get("/panic", http.HandlerFunc(func(http.ResponseWriter, *http.Request) { panic("shadow") }))
+
+	var nerr *types.RouterNotFoundError
+	a.True(errors.As(g.Mirror("not-exists", &Mirror{Shadow: "shadow"}), &nerr)).Equal(nerr.Name, "not-exists")
+	var verr *types.InvalidValueError
+	a.True(errors.As(g.Mirror("main", &Mirror{Shadow: "main"}), &verr)).Equal(verr.Name, "Mirror.Shadow").
+		True(errors.As(g.Mirror("main", &Mirror{Shadow: "shadow", Percent: 101}), &verr)).Equal(verr.Name, "Mirror.Percent").
+		True(errors.As(g.Mirror("main", &Mirror{Shadow: "shadow", MaxConcurrent: -1}), &verr)).Equal(verr.Name, "Mirror.MaxConcurrent")
+
+	results := make(chan *MirrorResult, 10)
+	a.NotError(g.Mirror("main", &Mirror{
+		Shadow:      "shadow",
+		Percent:     100,
+		MaxBodySize: 10,
+		Report:      func(r *MirrorResult) { results <- r },
+	}))
+
+	req := rest.Post(a, "/v1/users", []byte("abc")).Request()
+	ctx, cancel := context.WithCancel(context.WithValue(req.Context(), key{}, "v"))
+	w := httptest.NewRecorder()
+	g.ServeHTTP(w, req.WithContext(ctx))
+	cancel() // 原请求的取消不影响影子路由
+	a.Equal(w.Code, 201).Equal(w.Body.String(), "abc")
+	result := <-results
+	a.Equal(result.Status, 202).
+		Equal(result.Router, "main").
+		Equal(result.Shadow, "shadow").
+		True(result.Latency >= time.Millisecond).
+		Nil(result.Panic).
+		Equal(<-shadowBodies, "abc")
+
+	// 超过大小限制
+	rest.Post(a, "/v1/users", []byte("0123456789abcdef")).Do(g).Status(201).StringBody("0123456789abcdef")
+	select {
+	case <-results:
+		a.TB().Error("不应该复制超过大小限制的请求")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	// panic
+	rest.Get(a, "/v1/panic").Do(g).Status(203)
+	result = <-results
+	a.Equal(result.Status, http.StatusInternalServerError).Equal(result.Panic, "shadow")
+
+	// 影子路由中不存在
+	rest.Get(a, "/v1/v2-only").Do(g).Status(204)
+	result = <-results
+	a.Equal(result.Status, http.StatusNotFound)
+
+	// Percent 为 0
+	a.NotError(g.Mirror("main", &Mirror{Shadow: "shadow", Report: func(r *MirrorResult) { results <- r }}))
+	rest.Post(a, "/v1/users", []byte("abc")).Do(g).Status(201)
+	select {
+	case <-results:
+		a.TB().Error("Percent 为 0 时不应该复制请求")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	// 取消
+	a.NotError(g.Mirror("main", nil))
+	a.Nil(g.Router("main").mirror.Load())
+}
+
+func TestGroup_Mirror_maxConcurrent(t *testing.T) {
+	a := assert.New(t, false)
+
+	g := newGroup(a)
+	g.New("main", NewPathVersion("", "v1")).Get("/users", rest.BuildHandler(a, 201, "", nil))
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	g.New("shadow", NewPathVersion("", "v1", "v2")).
+		Get("/users", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			started <- struct{}{}
+			<-release
+			w.WriteHeader(202)
+		}))
+
+	results := make(chan *MirrorResult, 10)
+	a.NotError(g.Mirror("main", &Mirror{
+		Shadow:        "shadow",
+		Percent:       100,
+		MaxConcurrent: 1,
+		Report:        func(r *MirrorResult) { results <- r },
+	}))
+	a.Equal(cap(g.Router("main").mirror.Load().sem), 1)
+
+	rest.Get(a, "/v1/users").Do(g).Status(201)
+	<-started
+
+	// 超过 MaxConcurrent，原请求不受影响。
+	rest.Get(a, "/v1/users").Do(g).Status(201)
+	result := <-results
+	a.True(result.Dropped).
+		Equal(result.Router, "main").
+		Equal(result.Shadow, "shadow").
+		Equal(result.Status, 0)
+
+	close(release)
+	result = <-results
+	a.False(result.Dropped).Equal(result.Status, 202)
+
+	// 释放之后可以继续复制
+	rest.Get(a, "/v1/users").Do(g).Status(201)
+	<-started
+	result = <-results
+	a.False(result.Dropped).Equal(result.Status, 202)
+
+	// 默认值
+	a.NotError(g.Mirror("main", &Mirror{Shadow: "shadow"}))
+	a.Equal(cap(g.Router("main").mirror.Load().sem), defaultMirrorConcurrent)
+}
+
+// 不论是否为 fallthrough 模式，都只复制匹配到路由项的请求。
+func TestGroup_Mirror_notFound(t *testing.T) {
+	a := assert.New(t, false)
+
+	for _, fallthrough_ := range []bool{false, true} {
+		g := newGroup(a, WithFallthrough(fallthrough_))
+		g.New("main", NewPathVersion("", "v1")).Get("/users", rest.BuildHandler(a, 201, "", nil))
+		g.New("shadow", NewPathVersion("", "v1", "v2")).Get("/users", rest.BuildHandler(a, 202, "", nil))
+
+		results := make(chan *MirrorResult, 10)
+		a.NotError(g.Mirror("main", &Mirror{
+			Shadow:  "shadow",
+			Percent: 100,
+			Report:  func(r *MirrorResult) { results <- r },
+		}))
+
+		rest.Get(a, "/v1/not-exists").Do(g).Status(http.StatusNotFound)
+		rest.Post(a, "/v1/users", nil).Do(g).Status(http.StatusMethodNotAllowed)
+		select {
+		case <-results:
+			a.TB().Errorf("fallthrough=%v 时不应该复制 404 和 405 的请求", fallthrough_)
+		case <-time.After(20 * time.Millisecond):
+		}
+
+		rest.Get(a, "/v1/users").Do(g).Status(201)
+		a.Equal((<-results).Status, 202)
+	}
+}
+
+// 被维护模式、禁用以及弃用拒绝的请求不会被复制
+func TestGroup_Mirror_rejected(t *testing.T) {
+	a := assert.New(t, false)
+
+	g := newGroup(a)
+	r := g.New("main", NewPathVersion("", "v1"))
+	r.Post("/users", rest.BuildHandler(a, 201, "", nil)).
+		Post("/posts", rest.BuildHandler(a, 201, "", nil)).
+		Post("/tags", rest.BuildHandler(a, 201, "", nil))
+	g.New("shadow", NewPathVersion("", "v1", "v2")).
+		Post("/users", rest.BuildHandler(a, 202, "", nil)).
+		Post("/posts", rest.BuildHandler(a, 202, "", nil)).
+		Post("/tags", rest.BuildHandler(a, 202, "", nil))
+
+	results := make(chan *MirrorResult, 10)
+	a.NotError(g.Mirror("main", &Mirror{
+		Shadow:      "shadow",
+		Percent:     100,
+		MaxBodySize: 10,
+		Report:      func(r *MirrorResult) { results <- r },
+	}))
+
+	noMirror := func(msg string) {
+		a.TB().Helper()
+		select {
+		case <-results:
+			a.TB().Error(msg)
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+
+	// 维护模式
+	r.Maintenance(&Maintenance{})
+	rest.Post(a, "/v1/users", []byte("abc")).Do(g).Status(http.StatusServiceUnavailable)
+	noMirror("维护模式下不应该复制请求")
+	r.Maintenance(nil)
+
+	// 禁用
+	r.Disable("/users", http.StatusServiceUnavailable)
+	rest.Post(a, "/v1/users", []byte("abc")).Do(g).Status(http.StatusServiceUnavailable)
+	noMirror("被禁用的路由项不应该复制请求")
+
+	// 弃用
+	r.Deprecate("/posts", &Deprecation{Sunset: time.Now().Add(-time.Hour), Gone: true})
+	rest.Post(a, "/v1/posts", []byte("abc")).Do(g).Status(http.StatusGone)
+	noMirror("已经停止服务的路由项不应该复制请求")
+
+	// 正常的请求
+	rest.Post(a, "/v1/tags", []byte("abc")).Do(g).Status(201)
+	a.Equal((<-results).Status, 202)
+}
@@ -26,12 +26,19 @@ type (
 	Option func(*options)
 
 	options struct {
-		trace        any // 应该同 Router 的类型参数 T，为了不全局泛型化，用 any 代替。
-		lock         bool
-		cors         *cors
-		interceptors *syntax.Interceptors
-		urlDomain    string
-		recoverFunc  RecoverFunc
+		trace         any // 应该同 Router 的类型参数 T，为了不全局泛型化，用 any 代替。
+		lock          bool
+		cors          *cors
+		interceptors  *syntax.Interceptors
+		urlDomain     string
+		recoverFunc   RecoverFunc
+		status        any // 同 trace，应该是 func(int) T 类型。
+		fallThrough   bool
+		notAcceptable bool
+		priority      int
+		deprecation   *Deprecation
+		hostRoutes    bool
+		observers     []types.Observer
 	}
 
 	cors struct {
@@ -59,12 +66,18 @@ type (
 
 // Trace 一种简单的处理 TRACE 请求的方法
 //
+// 以 message/http 的形式原样返回请求内容，Authorization、Proxy-Authorization 和 Cookie
+// 以及 redact 中指定的报头不会被返回。Max-Forwards 的值无效时返回 400。
+//
 // 可以结合 [WithTrace] 处理。
-func Trace(w http.ResponseWriter, r *http.Request, body bool) { trace.Trace(w, r, body) }
+func Trace(w http.ResponseWriter, r *http.Request, body bool, redact ...string) {
+	trace.Trace(w, r, body, redact...)
+}
 
 // WithTrace 指定用于处理 TRACE 请求的方法
 //
 // T 的类型应该同 [NewRouter] 中的类型参数 T，否则会 panic。
+// 默认对所有路径启用 TRACE，可以通过 [Prefix.Trace] 按前缀启用或禁用。
 //
 // NOTE: [Trace] 提供了一种简单的 TRACE 处理方式。
 func WithTrace[T any](v T) Option { return func(o *options) { o.trace = v } }
@@ -83,6 +96,58 @@ func WithURLDomain(prefix string) Option { return func(o *options) { o.urlDomain
 // 如果多次指定，则最后一次启作用。
 func WithRecovery(f RecoverFunc) Option { return func(o *options) { o.recoverFunc = f } }
 
+// WithFallthrough 当 [Router] 中找不到路由项时是否继续查找 [Group] 中的下一个 [Router]
+//
+// 默认情况下，[Group] 在找到第一个 [Matcher] 符合要求的 [Router] 之后，
+// 不论该 [Router] 是否存在对应的路由项，都交由该 [Router] 处理。
+// 指定此选项之后，当 [Router] 返回 404 或是 405 时，会继续查找下一个符合要求的 [Router]，
+// 如果所有的 [Router] 都不存在该路由项，则由 [Group] 返回 404，
+// 如果存在 405，则由 [Group] 返回 405，且 Allow 报头为所有存在该路径的 [Router] 的请求方法的合集。
+//
+// 仅对 [NewGroup] 有效。
+func WithFallthrough(v bool) Option { return func(o *options) { o.fallThrough = v } }
+
+// WithNotAcceptable 当请求因为 [HeaderVersion] 不匹配而找不到 [Router] 时，由 [Group] 返回 406
+//
+// 默认情况下返回的是 404，指定此选项之后，会调用第一个拒绝该请求的 [HeaderVersion] 的
+// [HeaderVersion.NotAcceptable] 方法，输出 406 以及支持的版本号列表。
+// 仅对直接作为 [Router] 的 [Matcher] 的 [HeaderVersion] 有效，
+// 如果存在 405 的情况，则依然返回 405。输出的内容不会应用 [Group.Use] 添加的中间件。
+//
+// 仅对 [NewGroup] 有效。
+func WithNotAcceptable(v bool) Option { return func(o *options) { o.notAcceptable = v } }
+
+// WithPriority 指定 [Router] 在 [Group] 中的优先级
+//
+// 值越大，越早参与匹配，相同优先级的按添加顺序排列，默认值为 0。
+// 仅在通过 [Group.Add] 或是 [Group.New] 添加到 [Group] 时有效。
+func WithPriority(p int) Option { return func(o *options) { o.priority = p } }
+
+// WithHostRoutes 允许在路由项中包含域名
+//
+// 指定此选项之后，不以 / 开头的路由项将被视为域名加路径的形式，比如：
+//
+//	r.Get("{tenant}.example.com/users/{id}", h)
+//	r.Get("api.example.com/users", h)
+//	r.Get("/users", h) // 匹配其它域名
+//
+// 域名和路径中的参数都保存在同一个 [types.Context] 中。请求时会优先匹配带域名的路由项，
+// 只有当所有带域名的路由项都不匹配该路径时，才会匹配以 / 开头的路由项。
+// 域名部分不包含端口，且国际化域名会被转换为 punycode 格式，其限制可参考 [Hosts.Match]。
+//
+// [Router.URL] 在生成带域名的地址时，以 // 加上域名作为开头，
+// 如果指定了 [WithURLDomain]，则采用其中的协议部分，比如 https://example.com 会生成 https://tenant.example.com/users/1。
+func WithHostRoutes(v bool) Option { return func(o *options) { o.hostRoutes = v } }
+
+// WithObserver 指定路由变更事件的观察者
+//
+// 在添加、删除路由项，清除前缀以及应用中间件时触发，用于审计或是与外部系统同步路由信息等。
+// 在 [NewGroup] 中指定时，还会在 [Group] 添加和删除路由时触发，且会被传递给由 [Group.New] 创建的路由。
+// 多次调用会依次触发。
+func WithObserver(o ...types.Observer) Option {
+	return func(opt *options) { opt.observers = append(opt.observers, o...) }
+}
+
 // WithStatusRecovery 仅向客户端输出 status 状态码
 func WithStatusRecovery(status int) Option {
 	return WithRecovery(func(w http.ResponseWriter, msg any) {
@@ -123,6 +188,18 @@ func WithSLogRecovery(status int, l *slog.Logger) Option {
 	})
 }
 
+// WithStatusHandler 指定仅输出状态码时的处理对象
+//
+// 在内容协商失败、访问已弃用或是被禁用的路由项以及重定向等情况下，由 b 根据状态码生成处理对象，
+// 该对象会应用通过 [Router.Use] 添加的中间件，并由 [CallFunc] 调用。重定向时已经设置了 Location 报头。
+//
+// T 的类型应该同 [NewRouter] 中的类型参数 T，否则会 panic。
+// 如果未指定，T 为 [http.Handler] 或 [http.HandlerFunc] 时采用仅输出状态码的默认实现，
+// 其它类型则直接向客户端输出状态码，不经过中间件和 [CallFunc]。
+func WithStatusHandler[T any](b func(status int) T) Option {
+	return func(o *options) { o.status = b }
+}
+
 // WithInterceptor 针对带参数类型路由的拦截处理
 //
 // 在解析诸如 /authors/{id:\\d+} 带参数的路由项时，
@@ -204,6 +281,13 @@ func buildOption(o ...Option) (*options, error) {
 	return ret, nil
 }
 
+// 将所有的观察者合并为一个
+func (o *options) observer(e *types.Event) {
+	for _, f := range o.observers {
+		f(e)
+	}
+}
+
 func (o *options) sanitize() error {
 	if o.cors == nil {
 		o.cors = &cors{}
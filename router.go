@@ -5,9 +5,16 @@
 package mux
 
 import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"slices"
 	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/issue9/errwrap"
 
@@ -29,15 +36,35 @@ type (
 		call CallFunc[T]
 		ms   []types.Middleware[T]
 
-		cors        *cors
-		urlDomain   string
-		recoverFunc RecoverFunc
-		matcher     Matcher
+		cors         *cors
+		urlDomain    string
+		recoverFunc  RecoverFunc
+		status       atomic.Pointer[func(int) T] // 由 WithStatusHandler 指定
+		matcher      Matcher
+		priority     int
+		deprecations rules[*Deprecation]
+		hostRoutes   bool
+
+		aliasTree *tree.Tree[T] // 仅用于匹配别名
+		aliases   rules[*alias]
+
+		notFounds         rules[T] // 由 Prefix.NotFound 指定
+		methodNotAlloweds rules[types.BuildNodeHandler[T]]
+		disables          rules[int]                   // 被禁用的路由项，值为返回的状态码。
+		maintenance       atomic.Pointer[maintenance]  // 由 Router.Maintenance 指定
+		groupMaintenance  *atomic.Pointer[maintenance] // 由 Group 指定，在 maintenance 为空时采用。
+		inflight          inflight
+		mirror            atomic.Pointer[mirror[T]] // 由 Group.Mirror 指定
 	}
 
 	// CallFunc 指定如何调用用户给定的类型 T
 	CallFunc[T any] func(http.ResponseWriter, *http.Request, types.Route, T)
 
+	// Variant 同一路由项中根据媒体类型区分的处理对象
+	//
+	// 可通过 [Router.HandleVariants] 添加，在请求时根据 Accept 和 Content-Type 报头选择最合适的一个。
+	Variant[T any] = tree.Variant[T]
+
 	// Resource 以资源地址为对象的路由
 	Resource[T any] struct {
 		router  *Router[T]
@@ -52,8 +79,12 @@ type (
 		ms      []types.Middleware[T]
 	}
 
+	// 用于 HEAD 请求，丢弃所有输出内容，但是保留 Content-Length 报头。
+	//
+	// 不论是自动生成的还是通过 [Router.Handle] 明确指定的 HEAD 请求，都会采用此对象。
 	headResponse struct {
 		size int
+		auto bool // Content-Length 是否由 headResponse 设置
 		http.ResponseWriter
 	}
 )
@@ -72,36 +103,91 @@ func NewRouter[T any](
 	methodNotAllowedBuilder, optionsBuilder types.BuildNodeHandler[T],
 	o ...Option,
 ) *Router[T] {
-	opt, err := buildOption(o...)
+	r, err := TryNewRouter(name, call, notFound, methodNotAllowedBuilder, optionsBuilder, o...)
 	if err != nil {
 		panic(err)
 	}
+	return r
+}
+
+// TryNewRouter 声明路由
+//
+// 与 [NewRouter] 相同，但是在参数错误时返回错误信息，而不是 panic。
+func TryNewRouter[T any](
+	name string,
+	call CallFunc[T],
+	notFound T,
+	methodNotAllowedBuilder, optionsBuilder types.BuildNodeHandler[T],
+	o ...Option,
+) (*Router[T], error) {
+	if name == "" {
+		return nil, &types.InvalidValueError{Name: "name", Value: name}
+	}
+
+	opt, err := buildOption(o...)
+	if err != nil {
+		return nil, fmt.Errorf("router %s: %w", name, err)
+	}
 
 	r := &Router[T]{
-		tree: tree.New(name, opt.lock, opt.interceptors, notFound, opt.trace, methodNotAllowedBuilder, optionsBuilder),
-		call: call,
+		tree:      tree.New(name, opt.lock, opt.interceptors, notFound, opt.trace, methodNotAllowedBuilder, optionsBuilder),
+		call:      call,
+		aliasTree: tree.New(name, opt.lock, opt.interceptors, notFound, nil, methodNotAllowedBuilder, optionsBuilder),
 
 		cors:        opt.cors,
 		urlDomain:   opt.urlDomain,
 		recoverFunc: opt.recoverFunc,
+		priority:    opt.priority,
+		hostRoutes:  opt.hostRoutes,
+	}
+	if opt.status != nil {
+		b := opt.status.(func(int) T)
+		r.status.Store(&b)
+	} else if b := defaultStatusHandler[T](); b != nil {
+		r.status.Store(&b)
+	}
+	if len(opt.observers) > 0 {
+		r.tree.SetObserver(opt.observer)
 	}
 
-	return r
+	if opt.deprecation != nil {
+		r.deprecations.add("", true, opt.deprecation)
+	}
+
+	return r, nil
 }
 
-// Clean 清除当前路由组的所有路由项
-func (r *Router[T]) Clean() { r.tree.Clean("") }
+// Clean 清除当前路由组的所有路由项以及别名
+func (r *Router[T]) Clean() {
+	r.tree.Clean("")
+	r.aliasTree.Clean("")
+	r.aliases.update(func(s *ruleSet[*alias]) { clear(s.routes) })
+}
 
 // Routes 返回当前路由组的路由项
 //
 // 键名为请求地址，键值为对应的请求方法。
-func (r *Router[T]) Routes() map[string][]string { return r.tree.Routes() }
+// 不包含别名和被禁用的路由项，可分别通过 [Router.Aliases] 和 [Router.Disabled] 获取。
+func (r *Router[T]) Routes() map[string][]string {
+	routes := r.tree.Routes()
+	for pattern := range routes {
+		if _, found := r.disables.get(pattern); found {
+			delete(routes, pattern)
+		}
+	}
+	return routes
+}
 
 // Remove 移除指定的路由项
 //
 // 当未指定 methods 时，将删除所有 method 匹配的项。
 // 指定错误的 methods 值，将自动忽略该值。
-func (r *Router[T]) Remove(pattern string, methods ...string) { r.tree.Remove(pattern, methods...) }
+// 如果 pattern 为别名，则删除该别名，此时 methods 无效。
+func (r *Router[T]) Remove(pattern string, methods ...string) {
+	pattern = r.pattern(pattern)
+	r.tree.Remove(pattern, methods...)
+	r.removeAlias(pattern)
+}
 
 // Use 使用中间件
 //
@@ -115,6 +201,7 @@ func (r *Router[T]) Remove(pattern string, methods ...string) { r.tree.Remove(pa
 func (r *Router[T]) Use(m ...types.Middleware[T]) {
 	r.ms = append(r.ms, m...)
 	r.tree.ApplyMiddleware(m...)
+	r.applyErrorHandlersMiddleware(m...)
 }
 
 // Handle 添加一条路由数据
@@ -123,13 +210,64 @@ func (r *Router[T]) Use(m ...types.Middleware[T]) {
 // 若语法不正确，则直接 panic，可以通过 [CheckSyntax] 检测语法的有效性，其它接口也相同；
 // m 为应用于当前路由项的中间件；
 // methods 该路由项对应的请求方法，如果未指定值，则采用 [AnyMethods] 返回的方法；
+//
+// OPTIONS 和 HEAD 默认是自动生成的，也可以通过 methods 明确指定，此时将覆盖自动生成的处理方式，
+// 通过 [Router.Remove] 删除之后，又会恢复为自动生成的处理方式。
 func (r *Router[T]) Handle(pattern string, h T, m []types.Middleware[T], methods ...string) *Router[T] {
-	if err := r.tree.Add(pattern, h, slices.Concat(m, r.ms), methods...); err != nil {
+	if err := r.TryHandle(pattern, h, m, methods...); err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// TryHandle 添加一条路由数据
+//
+// 与 [Router.Handle] 相同，但是在出错时返回错误信息，而不是 panic。
+// 返回的错误信息中包含了 pattern 和 methods，调用方可以通过 [errors.Join] 将多个错误合并：
+//
+//	err := errors.Join(
+//	    r.TryHandle("/users", h1, nil, http.MethodGet),
+//	    r.TryHandle("/users/{id}", h2, nil, http.MethodGet, http.MethodDelete),
+//	)
+func (r *Router[T]) TryHandle(pattern string, h T, m []types.Middleware[T], methods ...string) error {
+	if err := r.tree.Add(r.pattern(pattern), h, slices.Concat(m, r.ms), methods...); err != nil {
+		return routeError(pattern, methods, err)
+	}
+	return nil
+}
+
+// HandleVariants 添加一条需要进行内容协商的路由数据
+//
+// 同一请求方法下可以有多个处理对象，在请求时根据 Accept 报头中的质量因子选择最合适的一个，
+// 没有可输出的媒体类型时返回 406，没有可接收的媒体类型时返回 415，可通过 [WithStatusHandler] 自定义。
+// 当 Accept 为空时，选择第一个符合 Content-Type 要求的对象。
+//
+// 其它参数与 [Router.Handle] 相同。
+func (r *Router[T]) HandleVariants(pattern string, vs []*Variant[T], m []types.Middleware[T], methods ...string) *Router[T] {
+	if err := r.TryHandleVariants(pattern, vs, m, methods...); err != nil {
 		panic(err)
 	}
 	return r
 }
 
+// TryHandleVariants 添加一条需要进行内容协商的路由数据
+//
+// 与 [Router.HandleVariants] 相同，但是在出错时返回错误信息，而不是 panic。
+func (r *Router[T]) TryHandleVariants(pattern string, vs []*Variant[T], m []types.Middleware[T], methods ...string) error {
+	if err := r.tree.AddVariants(r.pattern(pattern), vs, slices.Concat(m, r.ms), methods...); err != nil {
+		return routeError(pattern, methods, err)
+	}
+	return nil
+}
+
+// 为 err 加上路由项的信息
+func routeError(pattern string, methods []string, err error) error {
+	if len(methods) == 0 {
+		methods = AnyMethods()
+	}
+	return fmt.Errorf("%s %s: %w", strings.Join(methods, ","), pattern, err)
+}
+
 // Get 相当于 Router.Handle(pattern, h, http.MethodGet) 的简易写法
 //
 // h 不应该主动调用 WriteHeader，否则会导致 HEAD 请求获取不到 Content-Length 报头。
@@ -163,7 +301,17 @@ func (r *Router[T]) Any(pattern string, h T, m ...types.Middleware[T]) *Router[T
 // strict 是否检查路由是否真实存在以及参数是否符合要求；
 // pattern 为路由项的定义内容；
 // params 为路由项中的参数，键名为参数名，键值为参数值。
+//
+// 如果 pattern 为别名，生成的是其对应的路由项的地址。
 func (r *Router[T]) URL(strict bool, pattern string, params map[string]string) (string, error) {
+	pattern = r.pattern(pattern)
+	if a, found := r.aliases.get(pattern); found && a.strict {
+		pattern = a.target
+	}
+	if r.hostRoutes && pattern != "" && pattern[0] != '/' {
+		return r.hostURL(strict, pattern, params)
+	}
+
 	buf := errwrap.StringBuilder{}
 	buf.Grow(len(r.urlDomain) + len(pattern))
 
@@ -171,30 +319,159 @@ func (r *Router[T]) URL(strict bool, pattern string, params map[string]string) (
 		buf.WString(r.urlDomain)
 	}
 
+	if err := r.buildURL(&buf, strict, pattern, params); err != nil {
+		return "", err
+	}
+	return buf.String(), buf.Err
+}
+
+// 生成带域名的路由项的地址
+func (r *Router[T]) hostURL(strict bool, pattern string, params map[string]string) (string, error) {
+	buf := errwrap.StringBuilder{}
+	if err := r.buildURL(&buf, strict, pattern, params); err != nil {
+		return "", err
+	}
+	if buf.Err != nil {
+		return "", buf.Err
+	}
+	u := buf.String()
+
+	buf.Reset()
+	if i := strings.Index(r.urlDomain, "//"); i >= 0 {
+		buf.WString(r.urlDomain[:i])
+	}
+	buf.WString("//")
+
+	// 由 URLBuilder 生成的内容插入到域名与路径之间
+	host, path, found := strings.Cut(u, "/")
+	buf.WString(host)
+	if err := r.buildMatcherURL(&buf, params); err != nil {
+		return "", err
+	}
+	if found {
+		buf.WByte('/').WString(path)
+	}
+
+	return buf.String(), buf.Err
+}
+
+func (r *Router[T]) buildURL(buf *errwrap.StringBuilder, strict bool, pattern string, params map[string]string) error {
+	if !r.hostRoutes || pattern == "" || pattern[0] == '/' {
+		if err := r.buildMatcherURL(buf, params); err != nil {
+			return err
+		}
+	}
+
 	switch {
 	case len(pattern) == 0: // 无需要处理
 	case len(params) == 0:
 		buf.WString(pattern)
 	case strict:
-		if err := r.tree.URL(&buf, pattern, params); err != nil {
-			return "", err
-		}
+		return r.tree.URL(buf, pattern, params)
 	default:
-		if err := emptyInterceptors.URL(&buf, pattern, params); err != nil {
-			return "", err
+		return emptyInterceptors.URL(buf, pattern, params)
+	}
+	return nil
+}
+
+func (r *Router[T]) buildMatcherURL(buf *errwrap.StringBuilder, params map[string]string) error {
+	if b, ok := r.matcher.(URLBuilder); ok {
+		s, err := b.BuildURL(params)
+		if err != nil {
+			return err
 		}
+		buf.WString(s)
 	}
+	return nil
+}
 
-	return buf.String(), buf.Err
+// 规范路由项
+//
+// 在 [WithHostRoutes] 模式下，将路由项中的域名部分转换为小写的 punycode 格式。
+func (r *Router[T]) pattern(p string) string {
+	if !r.hostRoutes || p == "" || p[0] == '/' {
+		return p
+	}
+
+	host, path, found := strings.Cut(p, "/")
+	host = hostPattern(host)
+	if found {
+		return host + "/" + path
+	}
+	return host
 }
 
 func (r *Router[T]) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	ctx := types.NewContext()
+	ctx.Path = req.URL.Path
+	if r.inflight.begin() { // 已经由 Group.Drain 移除的路由，依然可以直接调用，只是不再计数。
+		defer r.inflight.end()
+	}
 	r.serveContext(w, req, ctx)
 	ctx.Destroy()
 }
 
+// 以 ctx.Path 作为路径进行匹配
 func (r *Router[T]) serveContext(w http.ResponseWriter, req *http.Request, ctx *types.Context) {
+	node, h, ok := r.handler(req, ctx)
+	r.serve(w, req, ctx, node, h, ok, false)
+}
+
+// 查找与请求对应的处理对象
+//
+// 在 [WithHostRoutes] 模式下，会先以域名加路径的形式查找。
+// 未找到任何路由项时，再查找别名、重定向和改写规则。
+// 如果需要重定向，返回的节点为 *aliasNode。
+func (r *Router[T]) handler(req *http.Request, ctx *types.Context) (types.Node, T, bool) {
+	node, h, ok := r.treeHandler(req, ctx)
+	for range maxRewrites {
+		if node != nil {
+			return node, h, ok
+		}
+
+		path := ctx.Path
+		n, a := r.matchAlias(ctx)
+		if a == nil {
+			return node, h, ok
+		}
+		if a.redirect > 0 {
+			var zero T
+			return &aliasNode{Node: n, alias: a}, zero, true
+		}
+
+		target, err := r.aliasPath(a, ctx)
+		if err != nil { // 参数不符合 target 的要求
+			ctx.Path = path
+			return nil, h, false
+		}
+		ctx.Path = target
+		node, h, ok = r.treeHandler(req, ctx)
+	}
+	return node, h, ok
+}
+
+func (r *Router[T]) treeHandler(req *http.Request, ctx *types.Context) (types.Node, T, bool) {
+	p := ctx.Path
+	if r.hostRoutes && strings.HasPrefix(p, "/") {
+		host, _ := requestHost(req)
+		ctx.Path = host + p
+		if node, h, ok := r.tree.Handler(ctx, req.Method); node != nil {
+			return node, h, ok
+		}
+		ctx.Path = p
+	}
+
+	node, h, ok := r.tree.Handler(ctx, req.Method)
+	if node == nil {
+		ctx.Path = p
+	}
+	return node, h, ok
+}
+
+// 调用由 [tree.Tree.Handler] 返回的处理对象
+//
+// mirrored 表示是否按 [Group.Mirror] 的设置复制请求，仅由 [Group] 分发的请求才需要复制。
+func (r *Router[T]) serve(w http.ResponseWriter, req *http.Request, ctx *types.Context, node types.Node, h T, ok, mirrored bool) {
 	if r.recoverFunc != nil {
 		defer func() {
 			if err := recover(); err != nil {
@@ -203,19 +480,88 @@ func (r *Router[T]) serveContext(w http.ResponseWriter, req *http.Request, ctx *
 		}()
 	}
 
-	ctx.Path = req.URL.Path
-	node, h, ok := r.tree.Handler(ctx, req.Method)
+	ctx.SetRouterName(r.Name())
 	ctx.SetNode(node)
 
+	if m := r.currentMaintenance(); m != nil && !m.allow(req, node) {
+		m.handle(w)
+		return
+	}
+
+	if node != nil && r.disabled(w, req, ctx, node) {
+		return
+	}
+
 	if ok { // !ok 即为 405 或是 404 状态
+		if n, isAlias := node.(*aliasNode); isAlias {
+			r.redirectAlias(w, req, ctx, n)
+			return
+		}
+
 		r.cors.handle(node, w.Header(), req)
 		if req.Method == http.MethodHead {
 			w = &headResponse{ResponseWriter: w}
 		}
+
+		if d, found := r.deprecations.get(node.Pattern()); found && d.handle(w.Header(), time.Now()) {
+			r.callStatus(w, req, ctx, http.StatusGone)
+			return
+		}
+
+		var status int
+		var negotiated bool
+		h, status, negotiated = r.tree.Negotiate(node, req.Method, h, req.Header.Get(header.Accept), req.Header.Get(header.ContentType))
+		if negotiated {
+			w.Header().Add(header.Vary, header.Accept)
+		}
+		if status != 0 {
+			r.callStatus(w, req, ctx, status)
+			return
+		}
+
+		if mirrored { // 在所有检测都通过之后才复制，避免影子路由处理原路由拒绝的请求。
+			req = r.mirrorRequest(req)
+		}
+	} else {
+		h = r.errorHandler(ctx.Path, node, h)
 	}
 	r.call(w, req, ctx, h)
 }
 
+// 仅输出状态码 status
+//
+// 由 [WithStatusHandler] 生成处理对象并通过 r.call 调用，未指定时直接输出状态码。
+func (r *Router[T]) callStatus(w http.ResponseWriter, req *http.Request, ctx *types.Context, status int) {
+	if b := r.status.Load(); b != nil {
+		r.call(w, req, ctx, (*b)(status))
+		return
+	}
+	http.Error(w, http.StatusText(status), status)
+}
+
+// 当 T 为 [http.Handler] 或 [http.HandlerFunc] 时返回仅输出状态码的默认实现，否则返回 nil。
+func defaultStatusHandler[T any]() func(int) T {
+	var zero T
+	switch any(&zero).(type) {
+	case *http.Handler, *http.HandlerFunc:
+	default:
+		return nil
+	}
+
+	return func(status int) T {
+		f := func(w http.ResponseWriter, _ *http.Request) { http.Error(w, http.StatusText(status), status) }
+
+		var h T
+		switch p := any(&h).(type) {
+		case *http.Handler:
+			*p = http.HandlerFunc(f)
+		case *http.HandlerFunc:
+			*p = f
+		}
+		return h
+	}
+}
+
 // Name 路由名称
 func (r *Router[T]) Name() string { return r.tree.Name() }
 
@@ -224,6 +570,21 @@ func (p *Prefix[T]) Handle(pattern string, h T, m []types.Middleware[T], methods
 	return p
 }
 
+// TryHandle 添加一条路由数据
+//
+// 与 [Prefix.Handle] 相同，但是在出错时返回错误信息，而不是 panic。
+func (p *Prefix[T]) TryHandle(pattern string, h T, m []types.Middleware[T], methods ...string) error {
+	return p.router.TryHandle(p.Pattern()+pattern, h, slices.Concat(m, p.ms), methods...)
+}
+
+// HandleVariants 添加一条需要进行内容协商的路由数据
+//
+// 参数可参考 [Router.HandleVariants]。
+func (p *Prefix[T]) HandleVariants(pattern string, vs []*Variant[T], m []types.Middleware[T], methods ...string) *Prefix[T] {
+	p.router.HandleVariants(p.Pattern()+pattern, vs, slices.Concat(m, p.ms), methods...)
+	return p
+}
+
 func (p *Prefix[T]) Get(pattern string, h T, m ...types.Middleware[T]) *Prefix[T] {
 	return p.Handle(pattern, h, m, http.MethodGet)
 }
@@ -251,6 +612,21 @@ func (p *Prefix[T]) Any(pattern string, h T, m ...types.Middleware[T]) *Prefix[T
 // Pattern 当前对象的路径
 func (p *Prefix[T]) Pattern() string { return p.pattern }
 
+// Trace 以 [Prefix.Pattern] 开头的路径是否可以处理 TRACE 请求
+//
+// 仅在指定了 [WithTrace] 时有效，默认所有路径都可以处理 TRACE 请求。
+// 存在多个符合要求的前缀时，以最长的前缀为准，比如仅允许 /debug 下的路径：
+//
+//	r.Prefix("").Trace(false)
+//	r.Prefix("/debug").Trace(true)
+//
+// 不可处理 TRACE 请求的路径，依然可以通过 [Router.Handle] 手动添加 TRACE 请求的处理方法。
+// 只要存在不可处理 TRACE 请求的路径，OPTIONS * 就不会包含 TRACE。
+func (p *Prefix[T]) Trace(enable bool) *Prefix[T] {
+	p.router.tree.SetTrace(p.router.pattern(p.Pattern()), enable)
+	return p
+}
+
 // Remove 删除指定匹配模式的路由项
 func (p *Prefix[T]) Remove(pattern string, methods ...string) {
 	p.router.Remove(p.Pattern()+pattern, methods...)
@@ -264,7 +640,7 @@ func (p *Prefix[T]) Remove(pattern string, methods ...string) {
 //	p1 := r.Prefix("prefix")
 //	p2 := r.Prefix("prefix")
 //	p2.Clean() 将同时清除 p1 的内容，因为有相同的前缀。
-func (p *Prefix[T]) Clean() { p.router.tree.Clean(p.Pattern()) }
+func (p *Prefix[T]) Clean() { p.router.tree.Clean(p.router.pattern(p.Pattern())) }
 
 // URL 根据参数生成地址
 func (p *Prefix[T]) URL(strict bool, pattern string, params map[string]string) (string, error) {
@@ -294,6 +670,21 @@ func (r *Resource[T]) Handle(h T, m []types.Middleware[T], methods ...string) *R
 	return r
 }
 
+// TryHandle 添加一条路由数据
+//
+// 与 [Resource.Handle] 相同，但是在出错时返回错误信息，而不是 panic。
+func (r *Resource[T]) TryHandle(h T, m []types.Middleware[T], methods ...string) error {
+	return r.router.TryHandle(r.pattern, h, slices.Concat(m, r.ms), methods...)
+}
+
+// HandleVariants 添加一条需要进行内容协商的路由数据
+//
+// 参数可参考 [Router.HandleVariants]。
+func (r *Resource[T]) HandleVariants(vs []*Variant[T], m []types.Middleware[T], methods ...string) *Resource[T] {
+	r.router.HandleVariants(r.pattern, vs, slices.Concat(m, r.ms), methods...)
+	return r
+}
+
 func (r *Resource[T]) Get(h T, m ...types.Middleware[T]) *Resource[T] {
 	return r.Handle(h, m, http.MethodGet)
 }
@@ -360,8 +751,43 @@ func (r *Resource[T]) Router() *Router[T] { return r.router }
 
 func (resp *headResponse) Write(bs []byte) (int, error) {
 	l := len(bs)
-	resp.size += l
+	resp.count(l)
+	return l, nil
+}
 
-	resp.Header().Set(header.ContentLength, strconv.Itoa(resp.size))
+func (resp *headResponse) WriteString(s string) (int, error) {
+	l := len(s)
+	resp.count(l)
 	return l, nil
 }
+
+// ReadFrom 实现 [io.ReaderFrom]
+//
+// 读取并丢弃 src 中的所有内容，仅计算其长度。
+func (resp *headResponse) ReadFrom(src io.Reader) (int64, error) {
+	n, err := io.Copy(io.Discard, src)
+	resp.count(int(n))
+	return n, err
+}
+
+// 增加内容的长度
+//
+// 如果用户已经明确指定了 Content-Length 或是 Transfer-Encoding，则不作修改。
+func (resp *headResponse) count(n int) {
+	resp.size += n
+
+	h := resp.Header()
+	if !resp.auto && (h.Get(header.ContentLength) != "" || h.Get(header.TransferEncoding) != "") {
+		return
+	}
+	resp.auto = true
+	h.Set(header.ContentLength, strconv.Itoa(resp.size))
+}
+
+func (resp *headResponse) Flush() { http.NewResponseController(resp.ResponseWriter).Flush() }
+
+func (resp *headResponse) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(resp.ResponseWriter).Hijack()
+}
+
+func (resp *headResponse) Unwrap() http.ResponseWriter { return resp.ResponseWriter }
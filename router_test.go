@@ -5,6 +5,8 @@
 package mux
 
 import (
+	"errors"
+	"io"
 	"net/http"
 	"slices"
 	"strings"
@@ -75,12 +77,50 @@ func TestRouter(t *testing.T) {
 	rest.Post(a, "/h/any", nil).Do(r).Status(206)
 	rest.NewRequest(a, http.MethodConnect, "/h/any").Do(r).Status(206)
 
-	// 不能主动添加 Head
+	// 主动添加 OPTIONS
+	r.Handle("/options", rest.BuildHandler(a, 202, "", nil), nil, http.MethodOptions)
+	rest.NewRequest(a, http.MethodOptions, "/options").Do(r).Status(202)
 	a.PanicString(func() {
 		r.Handle("/options", rest.BuildHandler(a, 202, "", nil), nil, http.MethodOptions)
 	}, "OPTIONS")
 }
 
+func TestRouter_customOptionsHead(t *testing.T) {
+	a := assert.New(t, false)
+	r := newRouter(a, "def")
+
+	r.Get("/res", rest.BuildHandler(a, 201, "get", nil))
+	rest.NewRequest(a, http.MethodHead, "/res").Do(r).Status(201).BodyEmpty()
+	rest.NewRequest(a, http.MethodOptions, "/res").Do(r).Status(200).Header(header.Allow, "GET, HEAD, OPTIONS")
+
+	r.Handle("/res", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set(header.AcceptPatch, "application/json")
+		w.WriteHeader(http.StatusNoContent)
+	}), nil, http.MethodOptions)
+	r.Handle("/res", rest.BuildHandler(a, 203, "", nil), nil, http.MethodHead)
+	a.Panic(func() { r.Handle("/res", rest.BuildHandler(a, 203, "", nil), nil, http.MethodHead) })
+
+	rest.NewRequest(a, http.MethodOptions, "/res").Do(r).Status(http.StatusNoContent).Header(header.AcceptPatch, "application/json")
+	rest.NewRequest(a, http.MethodHead, "/res").Do(r).Status(203)
+	rest.Get(a, "/res").Do(r).Status(201)
+
+	// 删除手动添加的，恢复为自动生成的。
+	r.Remove("/res", http.MethodOptions, http.MethodHead)
+	rest.NewRequest(a, http.MethodOptions, "/res").Do(r).Status(200).Header(header.Allow, "GET, HEAD, OPTIONS")
+	rest.NewRequest(a, http.MethodHead, "/res").Do(r).Status(201)
+
+	// 先添加 HEAD 再添加 GET
+	r.Handle("/head", rest.BuildHandler(a, 203, "", nil), nil, http.MethodHead)
+	rest.NewRequest(a, http.MethodOptions, "/head").Do(r).Status(200).Header(header.Allow, "HEAD, OPTIONS")
+	r.Get("/head", rest.BuildHandler(a, 201, "", nil))
+	rest.NewRequest(a, http.MethodHead, "/head").Do(r).Status(203)
+	rest.Get(a, "/head").Do(r).Status(201)
+	r.Remove("/head", http.MethodGet)
+	rest.NewRequest(a, http.MethodHead, "/head").Do(r).Status(203)
+	r.Remove("/head", http.MethodHead)
+	rest.NewRequest(a, http.MethodHead, "/head").Do(r).Status(404)
+}
+
 func TestRouter_Handle_Remove(t *testing.T) {
 	a := assert.New(t, false)
 	r := newRouter(a, "def")
@@ -119,6 +159,47 @@ func TestRouter_Handle_Remove(t *testing.T) {
 	rest.Get(a, "/api/1").Do(r).Status(http.StatusNotFound) // 整个节点被删除
 }
 
+func TestRouter_TryHandle(t *testing.T) {
+	a := assert.New(t, false)
+	r := newRouter(a, "def")
+
+	a.NotError(r.TryHandle("/users", rest.BuildHandler(a, 201, "", nil), nil, http.MethodGet))
+	err := errors.Join(
+		r.TryHandle("/users", rest.BuildHandler(a, 201, "", nil), nil, http.MethodGet, http.MethodPost),
+		r.TryHandle("/posts/{id}{id2}", rest.BuildHandler(a, 201, "", nil), nil),
+		r.Prefix("/p").TryHandle("/{id}/{id}", rest.BuildHandler(a, 201, "", nil), nil, http.MethodDelete),
+		r.Resource("/users").TryHandle(rest.BuildHandler(a, 201, "", nil), nil, "NOT-SUPPORTED"),
+		r.TryHandleVariants("/variants", nil, nil, http.MethodGet),
+	)
+	a.Error(err)
+	msg := err.Error()
+	a.Contains(msg, "GET,POST /users:").
+		Contains(msg, "GET,POST,DELETE,PUT,PATCH,CONNECT /posts/{id}{id2}:").
+		Contains(msg, "DELETE /p/{id}/{id}:").
+		Contains(msg, "NOT-SUPPORTED /users:").
+		Contains(msg, "GET /variants:")
+
+	// 出错的不会被添加
+	a.Equal(r.Routes(), map[string][]string{
+		"*":      {http.MethodOptions},
+		"/users": {http.MethodGet, http.MethodHead, http.MethodOptions},
+	})
+}
+
+func TestTryNewRouter(t *testing.T) {
+	a := assert.New(t, false)
+
+	r, err := TryNewRouter("", call, http.NotFoundHandler(), methodNotAllowedBuilder, optionsHandlerBuilder)
+	var ive *types.InvalidValueError
+	a.Nil(r).True(errors.As(err, &ive)).Equal(ive.Name, "name")
+
+	r, err = TryNewRouter("def", call, http.NotFoundHandler(), methodNotAllowedBuilder, optionsHandlerBuilder, WithAllowedCORS(-2))
+	a.Error(err).Nil(r).Contains(err.Error(), "def")
+
+	r, err = TryNewRouter("def", call, http.NotFoundHandler(), methodNotAllowedBuilder, optionsHandlerBuilder)
+	a.NotError(err).NotNil(r)
+}
+
 func TestRouter_Routes(t *testing.T) {
 	a := assert.New(t, false)
 
@@ -214,6 +295,100 @@ func TestRouter_Middleware(t *testing.T) {
 	rest.Get(a, "/get").Do(def).Status(201).StringBody("m0m1m2m3m4m5m6")
 }
 
+func TestRouter_HandleVariants(t *testing.T) {
+	a := assert.New(t, false)
+
+	def := newRouter(a, "def")
+	def.Use(tree.BuildTestMiddleware(a, "m1"))
+	vs := []*Variant[http.Handler]{
+		{Produces: []string{header.JSON}, Handler: rest.BuildHandler(a, 201, "json", nil)},
+		{Produces: []string{header.HTML}, Consumes: []string{header.FormData}, Handler: rest.BuildHandler(a, 202, "html", nil)},
+	}
+	def.HandleVariants("/posts", vs, nil, http.MethodGet, http.MethodPost)
+	def.Prefix("/p").HandleVariants("/posts", vs, nil, http.MethodGet)
+	def.Resource("/r/posts").HandleVariants(vs, nil, http.MethodGet)
+
+	a.PanicString(func() {
+		def.HandleVariants("/posts", vs, nil, http.MethodGet)
+	}, http.MethodGet)
+
+	rest.Get(a, "/posts").Do(def).Status(201).StringBody("jsonm1").Header(header.Vary, header.Accept)
+	rest.Get(a, "/posts").Header(header.Accept, "text/html,application/json;q=0.9").Do(def).Status(202).StringBody("htmlm1")
+	rest.Get(a, "/p/posts").Header(header.Accept, "text/*").Do(def).Status(202).StringBody("htmlm1")
+	rest.Get(a, "/r/posts").Header(header.Accept, "application/*").Do(def).Status(201).StringBody("jsonm1")
+	rest.NewRequest(a, http.MethodHead, "/posts").Header(header.Accept, "text/html").Do(def).Status(202).BodyEmpty()
+	rest.Get(a, "/posts").Header(header.Accept, "image/png").Do(def).Status(http.StatusNotAcceptable)
+	rest.Post(a, "/posts", nil).Header(header.ContentType, header.FormData).Header(header.Accept, "text/html").Do(def).Status(202)
+	rest.Post(a, "/posts", nil).Header(header.ContentType, header.FormData).Header(header.Accept, "application/json").Do(def).Status(201)
+
+	def.Use(tree.BuildTestMiddleware(a, "m2"))
+	rest.Get(a, "/posts").Header(header.Accept, "text/html").Do(def).Status(202).StringBody("htmlm1m2")
+
+	rest.Get(a, "/posts").Header(header.Accept, "image/png").Do(def).Status(http.StatusNotAcceptable).StringBody(http.StatusText(http.StatusNotAcceptable) + "\nm1m2")
+
+	// WithStatusHandler
+
+	def = newRouter(a, "def", WithStatusHandler(func(status int) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(status + 1) })
+	}))
+	def.HandleVariants("/posts", vs[1:], nil, http.MethodPost)
+	rest.Post(a, "/posts", nil).Header(header.ContentType, header.JSON).Do(def).Status(http.StatusUnsupportedMediaType + 1)
+	rest.Post(a, "/posts", nil).Header(header.Accept, header.JSON).Do(def).Status(http.StatusNotAcceptable + 1)
+}
+
+func TestRouter_hostRoutes(t *testing.T) {
+	a := assert.New(t, false)
+
+	r := newRouter(a, "host", WithHostRoutes(true), WithURLDomain("https://example.com"))
+	r.Get("{tenant}.example.com/users/{id}", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		a.Equal(req.URL.Path, "/users/1")
+		w.WriteHeader(201)
+	})).
+		Get("API.Example.com/users/{id}", rest.BuildHandler(a, 202, "", nil)).
+		Get("/users/{id}", rest.BuildHandler(a, 203, "", nil)).
+		Post("中文.com/users/{id}", rest.BuildHandler(a, 204, "", nil))
+
+	rest.Get(a, "http://abc.example.com/users/1").Do(r).Status(201)
+	rest.Get(a, "http://api.example.com:8080/users/1").Do(r).Status(202)
+	rest.Get(a, "http://other.com/users/1").Do(r).Status(203)
+	rest.Post(a, "http://中文.com/users/1", nil).Do(r).Status(204)
+	rest.Get(a, "http://中文.com/users/1").Do(r).Status(http.StatusMethodNotAllowed) // 优先匹配带域名的路由项
+	rest.Get(a, "http://abc.example.com/posts").Do(r).Status(http.StatusNotFound)
+
+	a.Equal(r.Routes(), map[string][]string{
+		"*":                               {http.MethodOptions},
+		"{tenant}.example.com/users/{id}": {http.MethodGet, http.MethodHead, http.MethodOptions},
+		"api.example.com/users/{id}":      {http.MethodGet, http.MethodHead, http.MethodOptions},
+		"/users/{id}":                     {http.MethodGet, http.MethodHead, http.MethodOptions},
+		"xn--fiq228c.com/users/{id}":      {http.MethodOptions, http.MethodPost},
+	})
+
+	u, err := r.URL(true, "{tenant}.example.com/users/{id}", map[string]string{"tenant": "abc", "id": "1"})
+	a.NotError(err).Equal(u, "https://abc.example.com/users/1")
+	u, err = r.URL(true, "/users/{id}", map[string]string{"id": "1"})
+	a.NotError(err).Equal(u, "https://example.com/users/1")
+
+	r.Remove("API.example.com/users/{id}")
+	rest.Get(a, "http://api.example.com/users/1").Do(r).Status(201)
+
+	// 未指定 WithURLDomain
+	r = newRouter(a, "host", WithHostRoutes(true))
+	r.Get("{tenant}.example.com/users/{id}", rest.BuildHandler(a, 201, "", nil))
+	u, err = r.URL(true, "{tenant}.example.com/users/{id}", map[string]string{"tenant": "abc", "id": "1"})
+	a.NotError(err).Equal(u, "//abc.example.com/users/1")
+
+	// Group 中的 URLBuilder
+	g := newGroup(a, WithFallthrough(true))
+	r = g.New("v1", NewPathVersion("v", "v1"), WithHostRoutes(true), WithURLDomain("https://example.com"))
+	r.Get("{tenant}.example.com/users", rest.BuildHandler(a, 201, "", nil))
+	rest.Get(a, "http://abc.example.com/v1/users").Do(g).Status(201)
+	rest.Get(a, "http://abc.example.com/v1/posts").Do(g).Status(404)
+	u, err = r.URL(true, "{tenant}.example.com/users", map[string]string{"tenant": "abc"})
+	a.NotError(err).Equal(u, "https://abc.example.com/v1/users")
+	u, err = r.URL(false, "/users", nil)
+	a.NotError(err).Equal(u, "https://example.com/v1/users")
+}
+
 func TestResource(t *testing.T) {
 	a := assert.New(t, false)
 	r := newRouter(a, "def")
@@ -301,6 +476,50 @@ func TestPrefix_Resource(t *testing.T) {
 	})
 }
 
+func TestRouter_tracePrefix(t *testing.T) {
+	a := assert.New(t, false)
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { Trace(w, r, false, "X-Secret") })
+	def := newRouter(a, "def", WithTrace[http.Handler](h))
+	rest.NewRequest(a, http.MethodOptions, "*").Do(def).Header(header.Allow, "OPTIONS, TRACE")
+	def.Get("/api/path", rest.BuildHandler(a, 201, "", nil))
+	rest.NewRequest(a, http.MethodOptions, "/api/path").Do(def).Header(header.Allow, "GET, HEAD, OPTIONS, TRACE")
+
+	def.Prefix("").Trace(false)
+	def.Prefix("/debug/").Trace(true)
+	def.Get("/debug/path", rest.BuildHandler(a, 201, "", nil)).
+		Handle("/custom", rest.BuildHandler(a, 202, "", nil), nil, http.MethodTrace)
+	a.Panic(func() {
+		def.Handle("/debug/custom", rest.BuildHandler(a, 202, "", nil), nil, http.MethodTrace)
+	})
+
+	rest.NewRequest(a, http.MethodTrace, "/debug/path").
+		Header(header.Authorization, "token").
+		Header("X-Secret", "secret").
+		Do(def).
+		Status(http.StatusOK).
+		Header(header.ContentType, header.MessageHTTP).
+		BodyFunc(func(a *assert.Assertion, body []byte) {
+			a.Contains(string(body), "TRACE /debug/path").
+				NotContains(string(body), "token").
+				NotContains(string(body), "secret")
+		})
+	rest.NewRequest(a, http.MethodOptions, "/debug/path").Do(def).Header(header.Allow, "GET, HEAD, OPTIONS, TRACE")
+
+	rest.NewRequest(a, http.MethodTrace, "/api/path").Do(def).Status(http.StatusMethodNotAllowed)
+	rest.NewRequest(a, http.MethodOptions, "/api/path").Do(def).Header(header.Allow, "GET, HEAD, OPTIONS")
+
+	rest.NewRequest(a, http.MethodTrace, "/custom").Do(def).Status(202)
+	rest.NewRequest(a, http.MethodOptions, "/custom").Do(def).Header(header.Allow, "OPTIONS, TRACE")
+	rest.NewRequest(a, http.MethodOptions, "*").Do(def).Header(header.Allow, "GET, OPTIONS, TRACE")
+
+	// 重新启用所有路径
+	def.Prefix("").Trace(true)
+	rest.NewRequest(a, http.MethodTrace, "/api/path").Do(def).Status(http.StatusOK)
+	rest.NewRequest(a, http.MethodOptions, "/api/path").Do(def).Header(header.Allow, "GET, HEAD, OPTIONS, TRACE")
+	rest.NewRequest(a, http.MethodTrace, "/custom").Do(def).Status(http.StatusOK)
+}
+
 func TestResource_URL(t *testing.T) {
 	a := assert.New(t, false)
 	def := newRouter(a, "def", WithAllowedCORS(3600))
@@ -480,3 +699,71 @@ func TestPrefix_URL(t *testing.T) {
 	url, err = p.URL(false, "/{action}/{id:\\d+}", map[string]string{"id": "1", "action": "blog"})
 	a.NotError(err).Equal(url, "https://example.com/api/blog/1")
 }
+
+func TestHeadResponse(t *testing.T) {
+	a := assert.New(t, false)
+
+	var _ io.ReaderFrom = &headResponse{}
+	var _ http.Flusher = &headResponse{}
+	var _ http.Hijacker = &headResponse{}
+	var _ io.StringWriter = &headResponse{}
+
+	r := newRouter(a, "def")
+	r.Get("/write", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte("123"))
+		io.WriteString(w, "45")
+	})).
+		Get("/copy", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			io.Copy(w, strings.NewReader("12345678"))
+		})).
+		Get("/explicit", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set(header.ContentLength, "100")
+			w.Write([]byte("123"))
+		})).
+		Get("/chunked", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set(header.TransferEncoding, "chunked")
+			w.Write([]byte("123"))
+		})).
+		Get("/flush", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			a.NotError(http.NewResponseController(w).Flush())
+			_, ok := w.(interface{ Unwrap() http.ResponseWriter })
+			a.True(ok)
+			w.Write([]byte("123"))
+		}))
+
+	rest.NewRequest(a, http.MethodHead, "/write").Do(r).Status(200).BodyEmpty().Header(header.ContentLength, "5")
+	rest.NewRequest(a, http.MethodHead, "/copy").Do(r).Status(200).BodyEmpty().Header(header.ContentLength, "8")
+	rest.NewRequest(a, http.MethodHead, "/explicit").Do(r).Status(200).BodyEmpty().Header(header.ContentLength, "100")
+	rest.NewRequest(a, http.MethodHead, "/chunked").Do(r).Status(200).BodyEmpty().Header(header.ContentLength, "")
+	rest.NewRequest(a, http.MethodHead, "/flush").Do(r).Status(200).BodyEmpty()
+}
+
+func TestRouter_observer(t *testing.T) {
+	a := assert.New(t, false)
+
+	events := make([]*types.Event, 0, 10)
+	r := newRouter(a, "def", WithObserver(func(e *types.Event) { events = append(events, e) }))
+
+	r.Get("/users", rest.BuildHandler(a, 201, "", nil)).
+		Any("/posts", rest.BuildHandler(a, 201, "", nil))
+	r.HandleVariants("/tags", []*Variant[http.Handler]{{Produces: []string{"application/json"}, Handler: rest.BuildHandler(a, 201, "", nil)}}, nil, http.MethodGet)
+	r.Remove("/users", http.MethodGet)
+	r.Remove("/not-exists")
+	r.Remove("/tags", http.MethodPost)    // 未注册的请求方法
+	r.Remove("/tags", http.MethodOptions) // 自动生成的 OPTIONS
+	r.Use(tree.BuildTestMiddleware(a, "m1"))
+	r.Prefix("/posts").Clean()
+	r.Prefix("/not-exists").Clean()
+	r.Get("/ps", rest.BuildHandler(a, 201, "", nil)).
+		Post("/ps", rest.BuildHandler(a, 201, "", nil))
+	r.Remove("/ps", http.MethodGet, http.MethodPut)
+
+	a.Length(events, 9).
+		Equal(events[0], &types.Event{Type: types.RouteAdded, Router: "def", Pattern: "/users", Methods: []string{http.MethodGet}}).
+		Equal(events[1], &types.Event{Type: types.RouteAdded, Router: "def", Pattern: "/posts", Methods: AnyMethods()}).
+		Equal(events[2], &types.Event{Type: types.RouteAdded, Router: "def", Pattern: "/tags", Methods: []string{http.MethodGet}}).
+		Equal(events[3], &types.Event{Type: types.RouteRemoved, Router: "def", Pattern: "/users", Methods: []string{http.MethodGet, http.MethodHead, http.MethodOptions}}).
+		Equal(events[4], &types.Event{Type: types.MiddlewareApplied, Router: "def"}).
+		Equal(events[5], &types.Event{Type: types.PrefixCleaned, Router: "def", Pattern: "/posts"}).
+		Equal(events[8], &types.Event{Type: types.RouteRemoved, Router: "def", Pattern: "/ps", Methods: []string{http.MethodGet, http.MethodHead}})
+}
@@ -0,0 +1,110 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+package mux
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+type (
+	// 按路由项的匹配模式保存的规则
+	//
+	// 可以针对单个路由项，也可以针对某一前缀的所有路由项，
+	// 读取时无需加锁，每次修改都会生成新的 ruleSet 对象。
+	rules[V any] struct {
+		locker sync.Mutex
+		set    atomic.Pointer[ruleSet[V]]
+	}
+
+	ruleSet[V any] struct {
+		routes   map[string]V
+		prefixes []*prefixRule[V] // 按前缀长度从大到小排列
+	}
+
+	prefixRule[V any] struct {
+		prefix string
+		value  V
+	}
+)
+
+// 设置规则
+//
+// prefix 表示 pattern 是否为前缀。
+func (r *rules[V]) add(pattern string, prefix bool, v V) {
+	r.update(func(s *ruleSet[V]) {
+		if !prefix {
+			s.routes[pattern] = v
+			return
+		}
+
+		s.prefixes = slices.DeleteFunc(s.prefixes, func(p *prefixRule[V]) bool { return p.prefix == pattern })
+		s.prefixes = append(s.prefixes, &prefixRule[V]{prefix: pattern, value: v})
+		slices.SortStableFunc(s.prefixes, func(a, b *prefixRule[V]) int { return len(b.prefix) - len(a.prefix) })
+	})
+}
+
+func (r *rules[V]) delete(pattern string, prefix bool) {
+	r.update(func(s *ruleSet[V]) {
+		if prefix {
+			s.prefixes = slices.DeleteFunc(s.prefixes, func(p *prefixRule[V]) bool { return p.prefix == pattern })
+		} else {
+			delete(s.routes, pattern)
+		}
+	})
+}
+
+// 以 f 的返回值替换所有规则的值
+func (r *rules[V]) apply(f func(V) V) {
+	r.update(func(s *ruleSet[V]) {
+		for k, v := range s.routes {
+			s.routes[k] = f(v)
+		}
+		for i, p := range s.prefixes { // prefixRule 可能正在被读取，只能生成新的对象。
+			s.prefixes[i] = &prefixRule[V]{prefix: p.prefix, value: f(p.value)}
+		}
+	})
+}
+
+func (r *rules[V]) update(f func(*ruleSet[V])) {
+	r.locker.Lock()
+	defer r.locker.Unlock()
+
+	s := &ruleSet[V]{}
+	if old := r.set.Load(); old != nil {
+		s.routes = maps.Clone(old.routes)
+		s.prefixes = slices.Clone(old.prefixes)
+	}
+	if s.routes == nil {
+		s.routes = make(map[string]V, 5)
+	}
+
+	f(s)
+	r.set.Store(s)
+}
+
+// 查找与 pattern 对应的规则
+//
+// 针对单个路由项的规则优先，其次是匹配的最长前缀。
+func (r *rules[V]) get(pattern string) (v V, found bool) {
+	s := r.set.Load()
+	if s == nil {
+		return v, false
+	}
+
+	if v, found = s.routes[pattern]; found {
+		return v, true
+	}
+
+	for _, p := range s.prefixes {
+		if strings.HasPrefix(pattern, p.prefix) {
+			return p.value, true
+		}
+	}
+	return v, false
+}
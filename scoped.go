@@ -0,0 +1,92 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+package mux
+
+import (
+	"github.com/issue9/mux/v9/internal/tree"
+	"github.com/issue9/mux/v9/types"
+)
+
+// NotFound 指定以 [Prefix.Pattern] 开头的路径在未找到路由项时的处理对象
+//
+// 可用于为不同的前缀返回不同格式的错误信息，比如 /api 返回 JSON，而其它页面返回 HTML：
+//
+//	r.Prefix("/api").NotFound(jsonNotFound)
+//
+// 存在多个符合要求的前缀时，以最长的前缀为准，都不符合时采用 [NewRouter] 中指定的 notFound。
+// 前缀按字面值与请求路径进行比较，所以前缀中不应该包含参数。
+// h 会应用通过 [Router.Use] 添加的中间件，可通过 [Prefix.ResetErrorHandlers] 取消。
+//
+// NOTE: 在 [WithFallthrough] 模式下，由 [Group] 处理的 404 不受此设置影响，
+// 同样地，由 [Group] 合并之后返回的 405 也不会调用 [Prefix.MethodNotAllowed] 指定的对象。
+func (p *Prefix[T]) NotFound(h T) *Prefix[T] {
+	r := p.router
+	r.notFounds.add(r.pattern(p.Pattern()), true, tree.ApplyMiddleware(h, "", "", r.Name(), r.ms...))
+	return p
+}
+
+// MethodNotAllowed 指定以 [Prefix.Pattern] 开头的路由项在 405 时的处理对象
+//
+// b 为 nil 表示取消。其它说明可参考 [Prefix.NotFound]，
+// 与其不同的是，405 是根据路由项而不是请求路径进行比较的，所以前缀中可以包含参数。
+//
+// NOTE: 在 [WithFallthrough] 模式下，由 [Group] 合并之后返回的 405 不受此设置影响。
+func (p *Prefix[T]) MethodNotAllowed(b types.BuildNodeHandler[T]) *Prefix[T] {
+	r := p.router
+	pattern := r.pattern(p.Pattern())
+	if b == nil {
+		r.methodNotAlloweds.delete(pattern, true)
+	} else {
+		r.methodNotAlloweds.add(pattern, true, applyBuilderMiddleware(b, r.Name(), r.ms...))
+	}
+	return p
+}
+
+// ResetErrorHandlers 取消由 [Prefix.NotFound] 和 [Prefix.MethodNotAllowed] 指定的处理对象
+func (p *Prefix[T]) ResetErrorHandlers() *Prefix[T] {
+	pattern := p.router.pattern(p.Pattern())
+	p.router.notFounds.delete(pattern, true)
+	p.router.methodNotAlloweds.delete(pattern, true)
+	return p
+}
+
+// 为 b 生成的对象应用中间件
+func applyBuilderMiddleware[T any](b types.BuildNodeHandler[T], router string, ms ...types.Middleware[T]) types.BuildNodeHandler[T] {
+	if len(ms) == 0 {
+		return b
+	}
+	return func(n types.Node) T { return tree.ApplyMiddleware(b(n), "", n.Pattern(), router, ms...) }
+}
+
+// 为由 [Prefix.NotFound]、[Prefix.MethodNotAllowed] 和 [WithStatusHandler] 指定的对象应用中间件
+func (r *Router[T]) applyErrorHandlersMiddleware(ms ...types.Middleware[T]) {
+	if b := r.status.Load(); b != nil && len(ms) > 0 {
+		prev := *b
+		f := func(status int) T { return tree.ApplyMiddleware(prev(status), "", "", r.Name(), ms...) }
+		r.status.Store(&f)
+	}
+
+	r.notFounds.apply(func(h T) T { return tree.ApplyMiddleware(h, "", "", r.Name(), ms...) })
+	r.methodNotAlloweds.apply(func(b types.BuildNodeHandler[T]) types.BuildNodeHandler[T] {
+		return applyBuilderMiddleware(b, r.Name(), ms...)
+	})
+}
+
+// 查找 404 和 405 状态下由 [Prefix] 指定的处理对象
+//
+// path 为请求路径，node 为 [tree.Tree.Handler] 返回的节点，为空表示 404。
+func (r *Router[T]) errorHandler(path string, node types.Node, h T) T {
+	if node == nil {
+		if nf, found := r.notFounds.get(path); found {
+			return nf
+		}
+		return h
+	}
+
+	if b, found := r.methodNotAlloweds.get(node.Pattern()); found {
+		return b(node)
+	}
+	return h
+}
@@ -0,0 +1,60 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+package mux
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/issue9/assert/v4"
+	"github.com/issue9/assert/v4/rest"
+
+	"github.com/issue9/mux/v9/internal/tree"
+	"github.com/issue9/mux/v9/types"
+)
+
+func TestPrefix_NotFound(t *testing.T) {
+	a := assert.New(t, false)
+
+	r := newRouter(a, "def")
+	r.Get("/api/users", rest.BuildHandler(a, 201, "", nil)).
+		Get("/api/v2/users/{id}", rest.BuildHandler(a, 201, "", nil)).
+		Get("/pages", rest.BuildHandler(a, 201, "", nil))
+
+	api := r.Prefix("/api")
+	api.NotFound(rest.BuildHandler(a, 451, "api", nil)).
+		MethodNotAllowed(tree.BuildTestNodeHandlerFunc(452))
+	v2 := api.Prefix("/v2")
+	v2.NotFound(rest.BuildHandler(a, 453, "v2", nil))
+	r.Prefix("/api/v2/users/{id}").MethodNotAllowed(tree.BuildTestNodeHandlerFunc(454))
+
+	rest.Get(a, "/api/not-exists").Do(r).Status(451).StringBody("api")
+	rest.Get(a, "/api/v2/not-exists").Do(r).Status(453).StringBody("v2") // 最长的前缀
+	rest.Get(a, "/not-exists").Do(r).Status(http.StatusNotFound)
+
+	rest.Post(a, "/api/users", nil).Do(r).Status(452)
+	rest.Post(a, "/api/v2/users/1", nil).Do(r).Status(454) // 405 可以包含参数
+	rest.Post(a, "/pages", nil).Do(r).Status(http.StatusMethodNotAllowed)
+
+	// 中间件
+	r.Use(types.MiddlewareFunc[http.Handler](func(next http.Handler, _, _, _ string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set("X-Middleware", "1")
+			next.ServeHTTP(w, req)
+		})
+	}))
+	rest.Get(a, "/api/not-exists").Do(r).Status(451).Header("X-Middleware", "1")
+	rest.Post(a, "/api/users", nil).Do(r).Status(452).Header("X-Middleware", "1")
+	v2.NotFound(rest.BuildHandler(a, 455, "v2", nil)) // 之后添加的也应用中间件
+	rest.Get(a, "/api/v2/not-exists").Do(r).Status(455).Header("X-Middleware", "1")
+
+	// 取消
+	v2.ResetErrorHandlers()
+	rest.Get(a, "/api/v2/not-exists").Do(r).Status(451)
+	api.MethodNotAllowed(nil)
+	rest.Post(a, "/api/users", nil).Do(r).Status(http.StatusMethodNotAllowed)
+	api.ResetErrorHandlers()
+	rest.Get(a, "/api/not-exists").Do(r).Status(http.StatusNotFound)
+}
@@ -0,0 +1,84 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+package mux
+
+import (
+	"net/http"
+
+	"github.com/issue9/mux/v9/types"
+)
+
+// Disable 暂时禁用路由项 pattern
+//
+// 与 [Router.Remove] 不同，禁用的路由项及其中间件依然保留，可以通过 [Router.Enable] 恢复。
+// 被禁用的路由项返回 status 状态码，比如 [http.StatusServiceUnavailable]，由 [WithStatusHandler] 生成处理对象，
+// 如果 status 为 0 或是 404，则与未找到路由项的处理方式相同，包括由 [Prefix.NotFound] 指定的处理对象。
+// status 只能是 0 或是 4xx 和 5xx，否则会触发 panic。
+//
+// 被禁用的路由项不会出现在 [Router.Routes] 的返回值中，可以通过 [Router.Disabled] 获取。
+// 针对单个路由项的设置优先于 [Prefix.Disable]。
+func (r *Router[T]) Disable(pattern string, status int) {
+	checkDisableStatus(status)
+	r.disables.add(r.pattern(pattern), false, status)
+}
+
+func checkDisableStatus(status int) {
+	if status != 0 && (status < 400 || status > 599) {
+		panic(&types.InvalidValueError{Name: "status", Value: status})
+	}
+}
+
+// Enable 恢复由 [Router.Disable] 禁用的路由项
+//
+// 不会影响由 [Prefix.Disable] 禁用的前缀。
+func (r *Router[T]) Enable(pattern string) { r.disables.delete(r.pattern(pattern), false) }
+
+// Disabled 返回已经被禁用的路由项
+//
+// 键名为路由项，键值为返回的状态码。包含了由 [Router.Disable] 和 [Prefix.Disable] 禁用的内容。
+func (r *Router[T]) Disabled() map[string]int {
+	m := make(map[string]int, 10)
+	for pattern := range r.tree.Routes() {
+		if status, found := r.disables.get(pattern); found {
+			m[pattern] = status
+		}
+	}
+	return m
+}
+
+// Disable 暂时禁用所有以 [Prefix.Pattern] 开头的路由项
+//
+// 存在多个符合要求的前缀时，以最长的前缀为准。其它可参考 [Router.Disable]。
+func (p *Prefix[T]) Disable(status int) {
+	checkDisableStatus(status)
+	p.router.disables.add(p.router.pattern(p.Pattern()), true, status)
+}
+
+// Enable 恢复由 [Prefix.Disable] 禁用的前缀
+func (p *Prefix[T]) Enable() { p.router.disables.delete(p.router.pattern(p.Pattern()), true) }
+
+// Disable 暂时禁用当前资源
+//
+// 可参考 [Router.Disable]。
+func (r *Resource[T]) Disable(status int) { r.router.Disable(r.Pattern(), status) }
+
+// Enable 恢复由 [Resource.Disable] 禁用的资源
+func (r *Resource[T]) Enable() { r.router.Enable(r.Pattern()) }
+
+// 如果 node 被禁用，输出相应的内容并返回 true。
+func (r *Router[T]) disabled(w http.ResponseWriter, req *http.Request, ctx *types.Context, node types.Node) bool {
+	status, found := r.disables.get(node.Pattern())
+	if !found {
+		return false
+	}
+
+	if status == 0 || status == http.StatusNotFound {
+		ctx.SetNode(nil)
+		r.call(w, req, ctx, r.errorHandler(node.Pattern(), nil, r.tree.NotFound()))
+	} else {
+		r.callStatus(w, req, ctx, status)
+	}
+	return true
+}
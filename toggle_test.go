@@ -0,0 +1,63 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+package mux
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/issue9/assert/v4"
+	"github.com/issue9/assert/v4/rest"
+
+	"github.com/issue9/mux/v9/internal/tree"
+)
+
+func TestRouter_Disable(t *testing.T) {
+	a := assert.New(t, false)
+
+	r := newRouter(a, "def")
+	r.Get("/users", rest.BuildHandler(a, 201, "", nil)).
+		Get("/posts/{id}", rest.BuildHandler(a, 202, "", nil)).
+		Get("/posts/{id}/comments", rest.BuildHandler(a, 203, "", nil))
+	r.Prefix("/posts").NotFound(rest.BuildHandler(a, 451, "", nil))
+
+	r.Disable("/users", http.StatusServiceUnavailable)
+	rest.Get(a, "/users").Do(r).Status(http.StatusServiceUnavailable)
+	rest.Post(a, "/users", nil).Do(r).Status(http.StatusServiceUnavailable)
+
+	p := r.Prefix("/posts")
+	p.Disable(0)
+	rest.Get(a, "/posts/1").Do(r).Status(451) // 采用 Prefix.NotFound
+	rest.Get(a, "/posts/1/comments").Do(r).Status(451)
+
+	res := r.Resource("/posts/{id}/comments")
+	res.Disable(http.StatusNotFound)
+	a.Equal(r.Disabled(), map[string]int{
+		"/users":               http.StatusServiceUnavailable,
+		"/posts/{id}":          0,
+		"/posts/{id}/comments": http.StatusNotFound,
+	})
+	a.Equal(r.Routes(), map[string][]string{"*": {http.MethodOptions}})
+
+	// 无效的状态码
+	a.Panic(func() { r.Disable("/users", 42) })
+	a.Panic(func() { p.Disable(http.StatusOK) })
+
+	// 经过中间件
+	r.Use(tree.BuildTestMiddleware(a, "m1"))
+	rest.Get(a, "/users").Do(r).Status(http.StatusServiceUnavailable).
+		StringBody(http.StatusText(http.StatusServiceUnavailable) + "\nm1")
+
+	// 恢复
+	r.Enable("/users")
+	rest.Get(a, "/users").Do(r).Status(201)
+	p.Enable()
+	rest.Get(a, "/posts/1").Do(r).Status(202)
+	rest.Get(a, "/posts/1/comments").Do(r).Status(451) // 单独禁用的依然有效
+	res.Enable()
+	rest.Get(a, "/posts/1/comments").Do(r).Status(203)
+	a.Empty(r.Disabled())
+	a.Length(r.Routes(), 4)
+}
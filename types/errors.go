@@ -0,0 +1,174 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+package types
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// 语法错误的原因
+const (
+	SyntaxEmpty          SyntaxReason = iota // 路由项为空
+	SyntaxInvalid                            // 无效的参数语法，比如 {} 或是 {:rule}
+	SyntaxAdjacentParams                     // 两个参数连续出现
+	SyntaxDuplicateParam                     // 存在相同名称的参数
+	SyntaxTooLong                            // 单个节点的长度过长
+	SyntaxInvalidRegexp                      // 无效的正则表达式
+)
+
+var localizer atomic.Pointer[Localizer]
+
+type (
+	// Localizer 将错误信息本地化
+	//
+	// err 为本包中定义的错误类型，比如 [*AmbiguousError]，可通过 [errors.As] 获取具体的字段。
+	// 返回空值表示采用默认的英文信息。
+	Localizer func(err error) string
+
+	// SyntaxReason 语法错误的原因
+	SyntaxReason int8
+
+	// SyntaxError 路由项的语法错误
+	SyntaxError struct {
+		Pattern string // 出错的路由项
+		Offset  int    // 出错的位置，以字节为单位。
+		Reason  SyntaxReason
+		Err     error // 底层的错误信息，比如正则表达式的编译错误，可能为空。
+	}
+
+	// AmbiguousError 路由项与已有的路由项存在歧义
+	//
+	// 比如 /posts/{id} 与 /posts/{name}，两者可以匹配的内容是完全相同的。
+	AmbiguousError struct {
+		Pattern  string // 新添加的路由项
+		Existing string // 已经存在的路由项
+	}
+
+	// MethodExistsError 路由项的请求方法已经存在
+	MethodExistsError struct {
+		Pattern string
+		Method  string
+	}
+
+	// UnsupportedMethodError 不支持的请求方法
+	UnsupportedMethodError struct {
+		Pattern string
+		Method  string
+	}
+
+	// ParamMismatchError 生成地址时参数与路由项不匹配
+	ParamMismatchError struct {
+		Pattern string
+		Param   string // 参数名称
+		Value   string // 参数的值
+		Missing bool   // 是否缺少该参数
+	}
+
+	// RouteNotFoundError 路由项不存在
+	RouteNotFoundError struct {
+		Pattern string
+	}
+
+	// RouterNotFoundError 不存在指定名称的路由
+	RouterNotFoundError struct {
+		Name string
+	}
+
+	// RouterExistsError 已经存在同名的路由
+	RouterExistsError struct {
+		Name string
+	}
+
+	// InvalidValueError 参数的值无效
+	InvalidValueError struct {
+		Name  string // 参数名称，比如 Mirror.Percent
+		Value any    // 参数的值
+	}
+)
+
+// SetLocalizer 指定错误信息的本地化方法
+//
+// 为 nil 时表示采用默认的英文信息。
+func SetLocalizer(l Localizer) {
+	if l == nil {
+		localizer.Store(nil)
+	} else {
+		localizer.Store(&l)
+	}
+}
+
+func localize(err error, def string) string {
+	if l := localizer.Load(); l != nil {
+		if msg := (*l)(err); msg != "" {
+			return msg
+		}
+	}
+	return def
+}
+
+func (r SyntaxReason) String() string {
+	switch r {
+	case SyntaxEmpty:
+		return "empty pattern"
+	case SyntaxInvalid:
+		return "invalid parameter syntax"
+	case SyntaxAdjacentParams:
+		return "adjacent parameters"
+	case SyntaxDuplicateParam:
+		return "duplicate parameter name"
+	case SyntaxTooLong:
+		return "segment too long"
+	case SyntaxInvalidRegexp:
+		return "invalid regular expression"
+	default:
+		return "unknown"
+	}
+}
+
+func (err *SyntaxError) Error() string {
+	msg := fmt.Sprintf("syntax error in %q at offset %d: %s", err.Pattern, err.Offset, err.Reason)
+	if err.Err != nil {
+		msg += ": " + err.Err.Error()
+	}
+	return localize(err, msg)
+}
+
+func (err *SyntaxError) Unwrap() error { return err.Err }
+
+func (err *AmbiguousError) Error() string {
+	return localize(err, fmt.Sprintf("%s is ambiguous with existing route %s", err.Pattern, err.Existing))
+}
+
+func (err *MethodExistsError) Error() string {
+	return localize(err, fmt.Sprintf("method %s already exists for %s", err.Method, err.Pattern))
+}
+
+func (err *UnsupportedMethodError) Error() string {
+	return localize(err, fmt.Sprintf("method %s is not supported for %s", err.Method, err.Pattern))
+}
+
+func (err *ParamMismatchError) Error() string {
+	if err.Missing {
+		return localize(err, fmt.Sprintf("missing param %s for %s", err.Param, err.Pattern))
+	}
+	return localize(err, fmt.Sprintf("value %q of param %s does not match %s", err.Value, err.Param, err.Pattern))
+}
+
+func (err *RouteNotFoundError) Error() string {
+	return localize(err, fmt.Sprintf("%s is not a registered route", err.Pattern))
+}
+
+func (err *RouterNotFoundError) Error() string {
+	return localize(err, fmt.Sprintf("router %s does not exist", err.Name))
+}
+
+func (err *RouterExistsError) Error() string {
+	return localize(err, fmt.Sprintf("router %s already exists", err.Name))
+}
+
+func (err *InvalidValueError) Error() string {
+	return localize(err, fmt.Sprintf("invalid value %v for %s", err.Value, err.Name))
+}
@@ -0,0 +1,70 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+package types
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/issue9/assert/v4"
+)
+
+var (
+	_ error = &SyntaxError{}
+	_ error = &AmbiguousError{}
+	_ error = &MethodExistsError{}
+	_ error = &UnsupportedMethodError{}
+	_ error = &ParamMismatchError{}
+	_ error = &RouteNotFoundError{}
+	_ error = &RouterNotFoundError{}
+	_ error = &RouterExistsError{}
+	_ error = &InvalidValueError{}
+)
+
+func TestSyntaxError(t *testing.T) {
+	a := assert.New(t, false)
+
+	err := &SyntaxError{Pattern: "/posts/{id}{id2}", Offset: 11, Reason: SyntaxAdjacentParams}
+	a.Equal(err.Error(), `syntax error in "/posts/{id}{id2}" at offset 11: adjacent parameters`)
+
+	_, rerr := regexp.Compile("(")
+	err = &SyntaxError{Pattern: "/{id:(}", Offset: 5, Reason: SyntaxInvalidRegexp, Err: rerr}
+	a.ErrorString(err, "invalid regular expression: ").
+		ErrorIs(err, rerr)
+
+	var serr *SyntaxError
+	a.True(errors.As(errors.Join(errors.New("other"), err), &serr)).
+		Equal(serr.Offset, 5)
+}
+
+func TestInvalidValueError(t *testing.T) {
+	a := assert.New(t, false)
+
+	a.Equal((&InvalidValueError{Name: "Mirror.Percent", Value: 101}).Error(), "invalid value 101 for Mirror.Percent").
+		Equal((&RouterNotFoundError{Name: "v1"}).Error(), "router v1 does not exist").
+		Equal((&RouterExistsError{Name: "v1"}).Error(), "router v1 already exists")
+}
+
+func TestSetLocalizer(t *testing.T) {
+	a := assert.New(t, false)
+	defer SetLocalizer(nil)
+
+	err := &MethodExistsError{Pattern: "/posts", Method: "GET"}
+	a.Equal(err.Error(), "method GET already exists for /posts")
+
+	SetLocalizer(func(err error) string {
+		var merr *MethodExistsError
+		if errors.As(err, &merr) {
+			return "请求方法 " + merr.Method + " 已经存在于 " + merr.Pattern
+		}
+		return ""
+	})
+	a.Equal(err.Error(), "请求方法 GET 已经存在于 /posts").
+		Equal((&RouteNotFoundError{Pattern: "/posts"}).Error(), "/posts is not a registered route") // 返回空值，采用默认值。
+
+	SetLocalizer(nil)
+	a.Equal(err.Error(), "method GET already exists for /posts")
+}
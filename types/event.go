@@ -0,0 +1,63 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+package types
+
+// 事件的类型
+const (
+	RouteAdded        EventType = iota + 1 // 添加了路由项
+	RouteRemoved                           // 删除了路由项
+	PrefixCleaned                          // 清除了指定前缀的路由项
+	MiddlewareApplied                      // 为路由应用了中间件
+	RouterAdded                            // 在路由组中添加了路由
+	RouterRemoved                          // 从路由组中删除了路由
+)
+
+type (
+	// EventType 事件的类型
+	EventType int8
+
+	// Event 路由的变更事件
+	Event struct {
+		Type EventType
+
+		// Router 路由的名称
+		Router string
+
+		// Pattern 路由项
+		//
+		// 对于 [PrefixCleaned] 为被清除的前缀，[MiddlewareApplied]、[RouterAdded] 和 [RouterRemoved] 为空。
+		Pattern string
+
+		// Methods 请求方法
+		//
+		// 仅对 [RouteAdded] 和 [RouteRemoved] 有效，
+		// 对于 [RouteRemoved] 为实际被删除的请求方法，包括随之删除的 HEAD 和 OPTIONS。
+		Methods []string
+	}
+
+	// Observer 路由变更事件的观察者
+	//
+	// 在变更完成之后同步调用，此时已经释放了相关的锁，可以在其中读取路由的信息。
+	Observer func(*Event)
+)
+
+func (t EventType) String() string {
+	switch t {
+	case RouteAdded:
+		return "route-added"
+	case RouteRemoved:
+		return "route-removed"
+	case PrefixCleaned:
+		return "prefix-cleaned"
+	case MiddlewareApplied:
+		return "middleware-applied"
+	case RouterAdded:
+		return "router-added"
+	case RouterRemoved:
+		return "router-removed"
+	default:
+		return "unknown"
+	}
+}
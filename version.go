@@ -0,0 +1,157 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+package mux
+
+import (
+	"strconv"
+	"strings"
+)
+
+type (
+	// 以数值表示的版本号，比如 1.2.3 表示为 [1, 2, 3]。
+	//
+	// 长度不固定，缺少的部分在比较时以 0 代替。
+	semver []int
+
+	// 版本号的约束条件，多个条件之间为与的关系。
+	versionRange []versionConstraint
+
+	versionConstraint struct {
+		// 空值表示与 raw 完全相同，* 表示以 v 为前缀，即 2.x 可以与 2.x.x 相匹配。
+		op  string
+		v   semver
+		raw string
+	}
+)
+
+// 解析版本号
+//
+// 可以带 v 前缀，x 和 * 表示任意值，其之后的内容将被忽略，比如 2.x 与 2.* 是相同的。
+// wildcard 表示是否包含了 x 或 *。
+func parseVersion(s string) (v semver, wildcard, ok bool) {
+	s = strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(s), "v"), "V")
+	if s == "" {
+		return nil, false, false
+	}
+
+	v = make(semver, 0, 3)
+	for p := range strings.SplitSeq(s, ".") {
+		if p == "x" || p == "X" || p == "*" {
+			return v, true, true
+		}
+
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return nil, false, false
+		}
+		v = append(v, n)
+	}
+	return v, false, true
+}
+
+func (v semver) compare(v2 semver) int {
+	for i := range max(len(v), len(v2)) {
+		if n1, n2 := v.at(i), v2.at(i); n1 != n2 {
+			return n1 - n2
+		}
+	}
+	return 0
+}
+
+// 第 i 个元素的值，不存在时返回 0。
+func (v semver) at(i int) int {
+	if i < len(v) {
+		return v[i]
+	}
+	return 0
+}
+
+// v 是否以 prefix 开头
+//
+// v 中缺少的部分以 0 代替，所以 2 以 2.0 开头，但是不以 2.3 开头。
+func (v semver) hasPrefix(prefix semver) bool {
+	for i, n := range prefix {
+		if v.at(i) != n {
+			return false
+		}
+	}
+	return true
+}
+
+// 解析版本号的约束条件
+//
+// 支持以下格式，多个条件之间以空格或是逗号分隔：
+//   - 2、2.3 完全相同；
+//   - 2.x、2.3.* 以 2 或 2.3 开头；
+//   - ^2.3 主版本号相同且不小于 2.3；
+//   - ~2.3 主版本号和次版本号相同且不小于 2.3；
+//   - >=2、>2、<=2、<2、=2 比较运算；
+func parseVersionRange(s string) (versionRange, bool) {
+	fields := strings.FieldsFunc(s, func(r rune) bool { return r == ' ' || r == ',' })
+	if len(fields) == 0 {
+		return nil, false
+	}
+
+	vr := make(versionRange, 0, len(fields))
+	for _, f := range fields {
+		var op string
+		for _, o := range []string{">=", "<=", ">", "<", "=", "^", "~"} {
+			if strings.HasPrefix(f, o) {
+				op = o
+				f = f[len(o):]
+				break
+			}
+		}
+
+		v, wildcard, ok := parseVersion(f)
+		if !ok {
+			return nil, false
+		}
+		if op == "" && wildcard {
+			op = "*"
+		}
+		vr = append(vr, versionConstraint{op: op, v: v, raw: f})
+	}
+	return vr, true
+}
+
+// raw 为版本号的原始值，v 为其解析后的值。
+func (vr versionRange) match(raw string, v semver) bool {
+	for _, c := range vr {
+		if !c.match(raw, v) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c versionConstraint) match(raw string, v semver) bool {
+	switch c.op {
+	case "":
+		return c.raw == raw
+	case "*":
+		return v.hasPrefix(c.v)
+	case "=":
+		return v.compare(c.v) == 0
+	case ">=":
+		return v.compare(c.v) >= 0
+	case ">":
+		return v.compare(c.v) > 0
+	case "<=":
+		return v.compare(c.v) <= 0
+	case "<":
+		return v.compare(c.v) < 0
+	case "^":
+		n := 1
+		if len(c.v) > 0 && c.v[0] == 0 { // 0.x 的次版本号也被视为不兼容
+			n = 2
+		}
+		return v.hasPrefix(c.v[:min(n, len(c.v))]) && v.compare(c.v) >= 0
+	case "~":
+		return v.hasPrefix(c.v[:min(2, len(c.v))]) && v.compare(c.v) >= 0
+	default:
+		return false
+	}
+}